@@ -0,0 +1,100 @@
+package circularbuffer
+
+// Numeric item types supported by NumericBuffer.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Summary of the items in a NumericBuffer. Min, Max and Mean are zero
+// when the buffer is empty.
+type Aggregates[T Number] struct {
+	Count int
+	Sum   T
+	Min   T
+	Max   T
+	Mean  float64
+}
+
+// Buffer of numbers keeping rolling aggregates of its current items,
+// updated as items are pushed, taken, evicted or removed, so reading
+// them doesn't need a pass over the buffer. Min and Max are
+// recomputed only after the current extreme leaves the buffer.
+//
+// Float sums are updated incrementally and may drift from the exact
+// sum of the items after many updates.
+type NumericBuffer[T Number] struct {
+	*Buffer[T]
+	agg *numericAgg[T]
+}
+
+type aggregator[T any] interface {
+	add(v T)
+	remove(v T)
+}
+
+type numericAgg[T Number] struct {
+	count    int
+	sum      T
+	min, max T
+	stale    bool // min or max left the buffer
+}
+
+// Create NumericBuffer object with a prealocated buffer of a given
+// size, configured by the options.
+func NewNumericBuffer[T Number](size uint, opts ...Option) *NumericBuffer[T] {
+	b := NewBuffer[T](size, opts...)
+	agg := &numericAgg[T]{}
+	b.agg = agg
+	return &NumericBuffer[T]{Buffer: b, agg: agg}
+}
+
+// Count, sum, min, max and mean of the items in the buffer.
+func (n *NumericBuffer[T]) Aggregates() Aggregates[T] {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	a := n.agg
+	if a.count == 0 {
+		return Aggregates[T]{}
+	}
+	if a.stale {
+		a.min, a.max = n.buffer[n.start], n.buffer[n.start]
+		for i := n.start; i != n.pos; i = (i + 1) & n.mask {
+			a.min = min(a.min, n.buffer[i])
+			a.max = max(a.max, n.buffer[i])
+		}
+		a.stale = false
+	}
+	return Aggregates[T]{
+		Count: a.count,
+		Sum:   a.sum,
+		Min:   a.min,
+		Max:   a.max,
+		Mean:  float64(a.sum) / float64(a.count),
+	}
+}
+
+func (a *numericAgg[T]) add(v T) {
+	if a.count == 0 {
+		a.min, a.max, a.stale = v, v, false
+	} else if !a.stale {
+		a.min = min(a.min, v)
+		a.max = max(a.max, v)
+	}
+	a.count++
+	a.sum += v
+}
+
+func (a *numericAgg[T]) remove(v T) {
+	a.count--
+	a.sum -= v
+	if a.count == 0 {
+		// Exact again, whatever the drift.
+		a.sum = 0
+	}
+	if v == a.min || v == a.max {
+		a.stale = true
+	}
+}
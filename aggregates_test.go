@@ -0,0 +1,50 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestNumericBuffer(t *testing.T) {
+	c := NewNumericBuffer[int](4)
+	if a := c.Aggregates(); a != (Aggregates[int]{}) {
+		t.Error(a)
+	}
+	c.NBPush(5)
+	c.NBPush(1)
+	c.NBPush(9)
+	if a := c.Aggregates(); a.Count != 3 || a.Sum != 15 || a.Min != 1 || a.Max != 9 || a.Mean != 5 {
+		t.Error(a)
+	}
+	// Evicts 5.
+	c.NBPush(3)
+	if a := c.Aggregates(); a.Count != 3 || a.Sum != 13 || a.Min != 1 || a.Max != 9 {
+		t.Error(a)
+	}
+	// The extremes leave.
+	c.Pop()
+	c.Pop()
+	c.NBPush(2)
+	if a := c.Aggregates(); a.Count != 2 || a.Sum != 3 || a.Min != 1 || a.Max != 2 {
+		t.Error(a)
+	}
+	c.RemoveIf(func(v int) bool { return v == 1 })
+	c.SwapNewest(7)
+	if a := c.Aggregates(); a.Count != 1 || a.Sum != 7 || a.Min != 7 || a.Max != 7 {
+		t.Error(a)
+	}
+	c.Get()
+	if a := c.Aggregates(); a != (Aggregates[int]{}) {
+		t.Error(a)
+	}
+
+	f := NewNumericBuffer[float64](10)
+	f.PushAll([]float64{0.5, -1.5, 4})
+	if a := f.Aggregates(); a.Sum != 3 || a.Min != -1.5 || a.Mean != 1 {
+		t.Error(a)
+	}
+	f.DrainAll()
+
+	if c.verifyIsEmpty() != true || f.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,134 @@
+package circularbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Header of the binary encoding, followed by the version, the size
+// and the number of items as uvarints, then the items, each as a
+// uvarint length and the bytes produced by the Codec.
+const (
+	binaryMagic   = "CBUF"
+	binaryVersion = 1
+)
+
+var errBadBinary = errors.New("circularbuffer: malformed binary encoding")
+
+// Encoding of single items for MarshalBinary and UnmarshalBinary.
+type Codec[T any] struct {
+	// Append the encoding of v to dst.
+	Append func(dst []byte, v T) []byte
+	// Decode an item from data, which holds exactly what Append
+	// produced.
+	Decode func(data []byte) (T, error)
+}
+
+// Set the item codec for MarshalBinary and UnmarshalBinary. Its item
+// type must match the buffer's. Buffers of string or []byte don't need
+// one.
+func WithCodec[T any](c Codec[T]) Option {
+	return func(cfg *config) {
+		cfg.codec = c
+	}
+}
+
+// Compact versioned encoding of the size and the items, oldest first,
+// for checkpoints. Like MarshalJSON, metadata, deadlines, keys,
+// counters and configuration are not included. Items are encoded with
+// the Codec set by WithCodec.
+func (b *Buffer[T]) MarshalBinary() ([]byte, error) {
+	codec, err := b.itemCodec()
+	if err != nil {
+		return nil, err
+	}
+	s := b.state()
+	data := append([]byte(binaryMagic), binaryVersion)
+	data = binary.AppendUvarint(data, uint64(s.Size))
+	data = binary.AppendUvarint(data, uint64(len(s.Items)))
+	var item []byte
+	for _, v := range s.Items {
+		item = codec.Append(item[:0], v)
+		data = binary.AppendUvarint(data, uint64(len(item)))
+		data = append(data, item...)
+	}
+	return data, nil
+}
+
+// Replace the buffer's size and items with the encoded ones, see
+// MarshalBinary. Like UnmarshalJSON, the items already in the buffer
+// are discarded without being evicted.
+func (b *Buffer[T]) UnmarshalBinary(data []byte) error {
+	codec, err := b.itemCodec()
+	if err != nil {
+		return err
+	}
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return errBadBinary
+	}
+	if data[len(binaryMagic)] != binaryVersion {
+		return errors.New("circularbuffer: unsupported binary encoding version")
+	}
+	data = data[len(binaryMagic)+1:]
+
+	uvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, false
+		}
+		data = data[n:]
+		return v, true
+	}
+	size, ok1 := uvarint()
+	count, ok2 := uvarint()
+	if !ok1 || !ok2 || size > maxEncodedSize || count >= size {
+		return errBadBinary
+	}
+	s := &bufferState[T]{Size: uint(size)}
+	for i := uint64(0); i < count; i++ {
+		n, ok := uvarint()
+		if !ok || n > uint64(len(data)) {
+			return errBadBinary
+		}
+		v, err := codec.Decode(data[:n])
+		if err != nil {
+			return err
+		}
+		s.Items = append(s.Items, v)
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return errBadBinary
+	}
+	return b.restore(s)
+}
+
+// The codec set by WithCodec, or the built-in one for strings and byte
+// slices.
+func (b *Buffer[T]) itemCodec() (*Codec[T], error) {
+	if b.codec != nil {
+		return b.codec, nil
+	}
+	var zero T
+	switch interface{}(zero).(type) {
+	case string:
+		return &Codec[T]{
+			Append: func(dst []byte, v T) []byte {
+				return append(dst, interface{}(v).(string)...)
+			},
+			Decode: func(data []byte) (T, error) {
+				return interface{}(string(data)).(T), nil
+			},
+		}, nil
+	case []byte:
+		return &Codec[T]{
+			Append: func(dst []byte, v T) []byte {
+				return append(dst, interface{}(v).([]byte)...)
+			},
+			Decode: func(data []byte) (T, error) {
+				return interface{}(append([]byte(nil), data...)).(T), nil
+			},
+		}, nil
+	}
+	return nil, errors.New("circularbuffer: no Codec for the item type, see WithCodec")
+}
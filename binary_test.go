@@ -0,0 +1,77 @@
+package circularbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	codec := Codec[uint32]{
+		Append: binary.LittleEndian.AppendUint32,
+		Decode: func(data []byte) (uint32, error) {
+			if len(data) != 4 {
+				return 0, errors.New("bad item")
+			}
+			return binary.LittleEndian.Uint32(data), nil
+		},
+	}
+	c := NewBuffer[uint32](4, WithCodec(codec))
+	for i := uint32(1); i <= 4; i++ {
+		c.NBPush(i)
+	}
+	data, err := c.MarshalBinary()
+	if err != nil || len(data) != 4+1+1+1+3*5 {
+		t.Error(len(data), err)
+	}
+
+	d := NewBuffer[uint32](1, WithCodec(codec))
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Error(err)
+	}
+	if s := d.Snapshot(); len(s) != 3 || s[0] != 2 || s[2] != 4 || d.Cap() != 3 {
+		t.Error(s)
+	}
+	if err := d.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error(err)
+	}
+	if err := d.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error(err)
+	}
+	// Hostile sizes are rejected, not allocated.
+	for _, size := range []uint64{1<<24 + 1, 1<<63 + 1} {
+		bad := binary.AppendUvarint([]byte("CBUF\x01"), size)
+		if err := d.UnmarshalBinary(append(bad, 0)); err == nil {
+			t.Error(size)
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic")
+			}
+		}()
+		ringSize(^uint(0))
+	}()
+
+	// No codec.
+	if _, err := NewBuffer[int](2).MarshalBinary(); err == nil {
+		t.Error(err)
+	}
+
+	s := NewBuffer[string](3)
+	s.NBPush("ab")
+	s.NBPush("")
+	data, _ = s.MarshalBinary()
+	e := NewBuffer[string](1)
+	if err := e.UnmarshalBinary(data); err != nil {
+		t.Error(err)
+	}
+	if v := e.Get(); v != "ab" {
+		t.Error(v)
+	}
+	e.Get()
+	if e.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,162 @@
+package circularbuffer
+
+import (
+	"sync"
+)
+
+// Ring fanning out every item to any number of readers, like several
+// `tail -f` on the same log. Each BroadcastReader has its own cursor,
+// reading doesn't consume the item for the others. Publishing never
+// waits: it overwrites the oldest item, and a reader that falls more
+// than a ring behind misses the overwritten items and is told how many.
+// Safe for concurrent use, each reader by one goroutine at a time.
+type BroadcastRing[T any] struct {
+	lock   sync.Mutex
+	items  *sync.Cond // broadcast on every publish and on Close
+	cells  []T
+	mask   uint64
+	next   uint64 // sequence of the next published item
+	closed bool
+}
+
+// Reader's cursor into a BroadcastRing.
+type BroadcastReader[T any] struct {
+	r   *BroadcastRing[T]
+	pos uint64 // sequence of the next item to read
+}
+
+// Create BroadcastRing keeping the last size items. The size is
+// rounded up to a power of two.
+func NewBroadcastRing[T any](size uint) *BroadcastRing[T] {
+	n := uint64(ringSize(size))
+	r := &BroadcastRing[T]{
+		cells: make([]T, n),
+		mask:  n - 1,
+	}
+	r.items = sync.NewCond(&r.lock)
+	return r
+}
+
+// Publish v to all readers, overwriting the oldest item if the ring is
+// full. Publishing to a closed ring panics.
+func (r *BroadcastRing[T]) Publish(v T) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		panic("circularbuffer: publish to closed ring")
+	}
+	r.cells[r.next&r.mask] = v
+	r.next++
+	r.items.Broadcast()
+}
+
+// Close the ring. Readers get the remaining items, then Next returns
+// ok false instead of blocking.
+func (r *BroadcastRing[T]) Close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closed = true
+	r.items.Broadcast()
+}
+
+// New reader, starting at the oldest item still in the ring.
+func (r *BroadcastRing[T]) NewReader() *BroadcastReader[T] {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return &BroadcastReader[T]{r: r, pos: r.oldestLocked()}
+}
+
+// Maximum number of items the ring keeps.
+func (r *BroadcastRing[T]) Cap() int {
+	return len(r.cells)
+}
+
+// Offsets of the oldest item still in the ring and of the next one to
+// be published. Every published item gets the next offset, starting at
+// 0.
+func (r *BroadcastRing[T]) Offsets() (oldest, next uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.oldestLocked(), r.next
+}
+
+// Sequence of the oldest item still in the ring.
+func (r *BroadcastRing[T]) oldestLocked() uint64 {
+	if n := uint64(len(r.cells)); r.next > n {
+		return r.next - n
+	}
+	return 0
+}
+
+// Get the next item, blocking until one is published. missed is the
+// number of items overwritten before this reader got to them, since
+// the previous call. ok is false once the ring is closed and the
+// reader has read everything.
+func (c *BroadcastReader[T]) Next() (v T, missed uint64, ok bool) {
+	r := c.r
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for c.pos == r.next && !r.closed {
+		r.items.Wait()
+	}
+	return c.readLocked()
+}
+
+// Like Next, but doesn't block. ok is false if there is nothing to
+// read.
+func (c *BroadcastReader[T]) TryNext() (v T, missed uint64, ok bool) {
+	r := c.r
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return c.readLocked()
+}
+
+func (c *BroadcastReader[T]) readLocked() (v T, missed uint64, ok bool) {
+	r := c.r
+	if oldest := r.oldestLocked(); c.pos < oldest {
+		missed = oldest - c.pos
+		c.pos = oldest
+	}
+	if c.pos == r.next {
+		return v, missed, false
+	}
+	v = r.cells[c.pos&r.mask]
+	c.pos++
+	return v, missed, true
+}
+
+// Number of published items this reader hasn't read yet, including
+// the ones it has already missed.
+func (c *BroadcastReader[T]) Lag() uint64 {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+
+	return c.r.next - c.pos
+}
+
+// Offset of the next item this reader reads. It may be already
+// overwritten, Next then skips to the oldest one.
+func (c *BroadcastReader[T]) Offset() uint64 {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+
+	return c.pos
+}
+
+// Move the reader to offset, to rewind within the items still in the
+// ring or to skip ahead. The offset is clamped to the ones in the
+// ring, up to the next to be published; returns the resulting one.
+func (c *BroadcastReader[T]) Seek(offset uint64) uint64 {
+	r := c.r
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	c.pos = min(max(offset, r.oldestLocked()), r.next)
+	return c.pos
+}
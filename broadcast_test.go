@@ -0,0 +1,103 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestBroadcastRing(t *testing.T) {
+	r := NewBroadcastRing[int](4)
+	r.Publish(1)
+	a := r.NewReader()
+	b := r.NewReader()
+	r.Publish(2)
+
+	// Both readers see every item.
+	for _, c := range []*BroadcastReader[int]{a, b} {
+		for i := 1; i <= 2; i++ {
+			if v, missed, ok := c.TryNext(); !ok || v != i || missed != 0 {
+				t.Error(v, missed, ok)
+			}
+		}
+		if _, _, ok := c.TryNext(); ok {
+			t.Error(ok)
+		}
+	}
+
+	// a keeps up, b falls behind and misses 3 and 4.
+	for i := 3; i <= 8; i++ {
+		r.Publish(i)
+		if v, missed, ok := a.Next(); !ok || v != i || missed != 0 {
+			t.Error(v, missed, ok)
+		}
+	}
+	if b.Lag() != 6 {
+		t.Error(b.Lag())
+	}
+	if v, missed, ok := b.Next(); !ok || v != 5 || missed != 2 {
+		t.Error(v, missed, ok)
+	}
+	if v, missed, ok := b.Next(); !ok || v != 6 || missed != 0 {
+		t.Error(v, missed, ok)
+	}
+
+	// A new reader starts at the oldest item.
+	if v, _, _ := r.NewReader().TryNext(); v != 5 {
+		t.Error(v)
+	}
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for {
+			if _, _, ok := a.Next(); !ok {
+				done <- n
+				return
+			}
+			n++
+		}
+	}()
+	r.Publish(9)
+	r.Close()
+	if n := <-done; n != 1 {
+		t.Error(n)
+	}
+	// Closed, b still gets what is left.
+	if v, _, ok := b.Next(); !ok || v != 7 {
+		t.Error(v, ok)
+	}
+}
+
+func TestBroadcastReaderSeek(t *testing.T) {
+	r := NewBroadcastRing[int](4)
+	c := r.NewReader()
+	for i := 0; i < 6; i++ {
+		r.Publish(i)
+	}
+	if oldest, next := r.Offsets(); oldest != 2 || next != 6 {
+		t.Error(oldest, next)
+	}
+	if c.Offset() != 0 || c.Lag() != 6 {
+		t.Error(c.Offset(), c.Lag())
+	}
+	if v, missed, _ := c.TryNext(); v != 2 || missed != 2 || c.Offset() != 3 {
+		t.Error(v, missed, c.Offset())
+	}
+
+	// Rewind, clamped to the oldest item.
+	if off := c.Seek(0); off != 2 || c.Lag() != 4 {
+		t.Error(off, c.Lag())
+	}
+	if off := c.Seek(4); off != 4 {
+		t.Error(off)
+	}
+	if v, _, _ := c.TryNext(); v != 4 {
+		t.Error(v)
+	}
+	// Skip to the end.
+	if off := c.Seek(100); off != 6 || c.Lag() != 0 {
+		t.Error(off, c.Lag())
+	}
+	if _, _, ok := c.TryNext(); ok {
+		t.Error(ok)
+	}
+}
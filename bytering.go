@@ -0,0 +1,268 @@
+package circularbuffer
+
+import (
+	"io"
+	"sync"
+)
+
+// Fixed-capacity ring of bytes, usable as a bounded in-memory pipe:
+// Write appends, Read consumes, with no per-item allocations. By
+// default Write blocks while the ring is full. In overwrite mode it
+// never blocks and drops the oldest bytes instead, like NBPush.
+//
+// Safe for concurrent use. After Close, Write returns ErrClosed and
+// Read drains what's left and then returns io.EOF.
+//
+// ReadFrom and WriteTo do I/O directly on the ring's memory, without
+// holding the lock. While WriteTo waits in dst.Write, an overwriting
+// write that needs to drop bytes waits for it.
+type ByteRing struct {
+	rlock     sync.Mutex // serializes readers
+	wlock     sync.Mutex // serializes writers
+	lock      sync.Mutex
+	readable  sync.Cond
+	writable  sync.Cond
+	buf       []byte
+	start     int  // offset of the oldest byte
+	n         int  // number of bytes stored
+	leased    int  // oldest bytes being written out by WriteTo
+	filling   bool // ReadFrom is reading into the ring
+	overwrite bool
+	closed    bool
+}
+
+var (
+	_ io.ReadWriteCloser = (*ByteRing)(nil)
+	_ io.ReaderFrom      = (*ByteRing)(nil)
+	_ io.WriterTo        = (*ByteRing)(nil)
+)
+
+// Create ByteRing holding up to size bytes. With overwrite set, writes
+// to a full ring drop the oldest bytes instead of blocking.
+func NewByteRing(size uint, overwrite bool) *ByteRing {
+	r := &ByteRing{buf: make([]byte, size), overwrite: overwrite}
+	r.readable.L = &r.lock
+	r.writable.L = &r.lock
+	return r
+}
+
+// Write all of p, blocking for space unless in overwrite mode. Returns
+// ErrClosed, and the number of bytes written so far, if the ring is
+// closed before p fits.
+func (r *ByteRing) Write(p []byte) (int, error) {
+	r.wlock.Lock()
+	defer r.wlock.Unlock()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	written := 0
+	for len(p) > 0 {
+		if r.closed {
+			return written, ErrClosed
+		}
+		if r.overwrite {
+			if len(p) > len(r.buf) {
+				// Only the tail of p survives anyway.
+				written += len(p) - len(r.buf)
+				p = p[len(p)-len(r.buf):]
+			}
+			if drop := r.n + len(p) - len(r.buf); drop > 0 {
+				if r.leased > 0 {
+					r.writable.Wait()
+					continue
+				}
+				r.discardLocked(drop)
+			}
+		} else if r.n == len(r.buf) {
+			r.writable.Wait()
+			continue
+		}
+		n := r.writeLocked(p)
+		written += n
+		p = p[n:]
+		r.readable.Broadcast()
+	}
+	return written, nil
+}
+
+// Read up to len(p) bytes, blocking until at least one is available.
+// Returns io.EOF once the ring is closed and empty.
+func (r *ByteRing) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for r.n == 0 {
+		if r.closed {
+			return 0, io.EOF
+		}
+		r.readable.Wait()
+	}
+	n := r.readLocked(p)
+	r.writable.Broadcast()
+	return n, nil
+}
+
+// Read from src into the ring until io.EOF, which is not returned as
+// an error. Blocks for space like Write and returns ErrClosed if the
+// ring is closed meanwhile. Reads go straight into the free space, or
+// in overwrite mode over the oldest bytes, while no reader is busy.
+func (r *ByteRing) ReadFrom(src io.Reader) (int64, error) {
+	r.wlock.Lock()
+	defer r.wlock.Unlock()
+	var total int64
+	for {
+		r.lock.Lock()
+		overwriting := false
+		for !r.closed && r.n == len(r.buf) {
+			// Overwrite only with the readers locked out, as they
+			// may be looking at the oldest bytes. Otherwise a
+			// reader is about to make room.
+			if r.overwrite && r.rlock.TryLock() {
+				overwriting = true
+				break
+			}
+			r.writable.Wait()
+		}
+		if r.closed {
+			r.lock.Unlock()
+			return total, ErrClosed
+		}
+		dst, _ := r.freeLocked()
+		if overwriting {
+			dst, _ = r.filledLocked()
+		}
+		r.filling = true
+		r.lock.Unlock()
+
+		n, err := src.Read(dst)
+
+		r.lock.Lock()
+		if overwriting {
+			// The oldest n bytes are gone, the new ones follow
+			// the newest.
+			r.discardLocked(n)
+			r.rlock.Unlock()
+		}
+		r.filling = false
+		r.n += n
+		if n > 0 {
+			r.readable.Broadcast()
+		}
+		r.lock.Unlock()
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Write the ring's contents to dst until the ring is closed and empty.
+// Blocks for data like Read. dst gets slices of the ring's memory.
+func (r *ByteRing) WriteTo(dst io.Writer) (int64, error) {
+	r.rlock.Lock()
+	defer r.rlock.Unlock()
+	var total int64
+	for {
+		r.lock.Lock()
+		for r.n == 0 && !r.closed {
+			r.readable.Wait()
+		}
+		if r.n == 0 {
+			r.lock.Unlock()
+			return total, nil
+		}
+		data, _ := r.filledLocked()
+		r.leased = len(data)
+		r.lock.Unlock()
+
+		n, err := dst.Write(data)
+
+		r.lock.Lock()
+		r.leased = 0
+		r.discardLocked(n)
+		r.writable.Broadcast()
+		r.lock.Unlock()
+		total += int64(n)
+		if err == nil && n < len(data) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Close the ring for writes, waking up blocked readers and writers.
+// Closing a closed ring has no effect.
+func (r *ByteRing) Close() error {
+	r.lock.Lock()
+	r.closed = true
+	r.readable.Broadcast()
+	r.writable.Broadcast()
+	r.lock.Unlock()
+	return nil
+}
+
+// Number of bytes in the ring.
+func (r *ByteRing) Length() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.n
+}
+
+// Maximum number of bytes the ring can hold.
+func (r *ByteRing) Cap() int {
+	return len(r.buf)
+}
+
+// The stored bytes, as up to two contiguous parts of buf.
+func (r *ByteRing) filledLocked() (a, b []byte) {
+	end := r.start + r.n
+	if end <= len(r.buf) {
+		return r.buf[r.start:end], nil
+	}
+	return r.buf[r.start:], r.buf[:end-len(r.buf)]
+}
+
+// The free space, as up to two contiguous parts of buf.
+func (r *ByteRing) freeLocked() (a, b []byte) {
+	end := r.start + r.n
+	if end >= len(r.buf) {
+		return r.buf[end-len(r.buf) : r.start], nil
+	}
+	return r.buf[end:], r.buf[:r.start]
+}
+
+func (r *ByteRing) writeLocked(p []byte) int {
+	a, b := r.freeLocked()
+	n := copy(a, p)
+	n += copy(b, p[n:])
+	r.n += n
+	return n
+}
+
+func (r *ByteRing) readLocked(p []byte) int {
+	a, b := r.filledLocked()
+	n := copy(p, a)
+	n += copy(p[n:], b)
+	r.discardLocked(n)
+	return n
+}
+
+func (r *ByteRing) discardLocked(n int) {
+	r.n -= n
+	r.start += n
+	if r.start >= len(r.buf) {
+		r.start -= len(r.buf)
+	}
+	if r.n == 0 && !r.filling {
+		// Keep the data contiguous for as long as possible.
+		r.start = 0
+	}
+}
@@ -0,0 +1,126 @@
+package circularbuffer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestByteRing(t *testing.T) {
+	r := NewByteRing(8, false)
+	if n, err := r.Write([]byte("hello")); n != 5 || err != nil {
+		t.Error(n, err)
+	}
+	p := make([]byte, 3)
+	if n, err := r.Read(p); n != 3 || err != nil || string(p) != "hel" {
+		t.Error(n, err, string(p))
+	}
+	// Wraps around the end.
+	r.Write([]byte("world!"))
+	if r.Length() != 8 || r.Cap() != 8 {
+		t.Error(r.Length(), r.Cap())
+	}
+
+	// A write to a full ring waits for a reader.
+	done := make(chan error)
+	go func() {
+		_, err := r.Write([]byte("xyz"))
+		done <- err
+	}()
+	p = make([]byte, 16)
+	n, _ := r.Read(p)
+	if string(p[:n]) != "loworld!" {
+		t.Error(string(p[:n]))
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+
+	r.Close()
+	if _, err := r.Write([]byte("a")); err != ErrClosed {
+		t.Error(err)
+	}
+	rest, err := io.ReadAll(r)
+	if string(rest) != "xyz" || err != nil {
+		t.Error(string(rest), err)
+	}
+}
+
+func TestByteRingOverwrite(t *testing.T) {
+	r := NewByteRing(4, true)
+	r.Write([]byte("abc"))
+	r.Write([]byte("de"))
+	p := make([]byte, 4)
+	if n, _ := r.Read(p); string(p[:n]) != "bcde" {
+		t.Error(string(p[:n]))
+	}
+	// Longer than the ring, only the tail is kept.
+	if n, err := r.Write([]byte("0123456789")); n != 10 || err != nil {
+		t.Error(n, err)
+	}
+	if n, _ := r.Read(p); string(p[:n]) != "6789" {
+		t.Error(string(p[:n]))
+	}
+	if r.Length() != 0 {
+		t.Error(r.Length())
+	}
+}
+
+func TestByteRingPipe(t *testing.T) {
+	r := NewByteRing(7, false)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	go func() {
+		r.Write(data)
+		r.Close()
+	}()
+	got, err := io.ReadAll(r)
+	if !bytes.Equal(got, data) || err != nil {
+		t.Error(len(got), err)
+	}
+}
+
+// Hides the ReaderFrom and WriterTo of bytes.Buffer from io.Copy.
+type onlyReader struct{ io.Reader }
+type onlyWriter struct{ io.Writer }
+
+func TestByteRingReadFromWriteTo(t *testing.T) {
+	r := NewByteRing(7, false)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	go func() {
+		if n, err := r.ReadFrom(onlyReader{bytes.NewReader(data)}); n != int64(len(data)) || err != nil {
+			t.Error(n, err)
+		}
+		r.Close()
+	}()
+	var out bytes.Buffer
+	n, err := r.WriteTo(onlyWriter{&out})
+	if n != int64(len(data)) || err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Error(n, err)
+	}
+	if _, err := r.ReadFrom(bytes.NewReader(data)); err != ErrClosed {
+		t.Error(err)
+	}
+
+	// Overwrite mode keeps the newest bytes.
+	r = NewByteRing(4, true)
+	r.Write([]byte("ab"))
+	r.ReadFrom(bytes.NewReader([]byte("cdefgh")))
+	r.Close()
+	out.Reset()
+	io.Copy(&out, r)
+	if out.String() != "efgh" {
+		t.Error(out.String())
+	}
+
+	// Again, with the oldest byte in the middle of the ring.
+	r = NewByteRing(4, true)
+	r.Write([]byte("abc"))
+	r.Read(make([]byte, 1))
+	r.ReadFrom(bytes.NewReader([]byte("defghi")))
+	r.Close()
+	out.Reset()
+	io.Copy(&out, r)
+	if out.String() != "fghi" {
+		t.Error(out.String())
+	}
+}
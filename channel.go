@@ -0,0 +1,47 @@
+package circularbuffer
+
+import (
+	"context"
+)
+
+// Deliver items, oldest first, on a channel, so that consumers can
+// select on the buffer together with timers and other channels. A pump
+// goroutine takes each item with GetContext and waits for the receiver
+// before taking the next one. The channel is closed once the buffer is
+// closed and drained, or ctx is done. An item already taken when ctx
+// is done is dropped, cancel only once nothing receives anymore.
+func (b *Buffer[T]) AsChannel(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for {
+			v, err := b.GetContext(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Drop-oldest buffered channel: items received from in are kept in a
+// Buffer of up to size items, evicting the oldest when the consumer
+// falls behind, and delivered on the returned channel. The returned
+// channel is closed once in is closed and the remaining items are
+// delivered. The goroutines behind it live until then, so the consumer
+// must keep receiving or in must be closed.
+func NewChannelBridge[T any](in <-chan T, size uint) <-chan T {
+	b := NewBuffer[T](size + 1)
+	go func() {
+		for v := range in {
+			b.NBPush(v)
+		}
+		b.Close()
+	}()
+	return b.AsChannel(context.Background())
+}
@@ -0,0 +1,55 @@
+package circularbuffer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAsChannel(t *testing.T) {
+	c := NewBuffer[int](4)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := c.AsChannel(ctx)
+	c.NBPush(1)
+	c.NBPush(2)
+	for i := 1; i <= 2; i++ {
+		if v := <-ch; v != i {
+			t.Error(v)
+		}
+	}
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error(ok)
+	}
+
+	ch = c.AsChannel(context.Background())
+	c.NBPush(3)
+	c.Close()
+	if v := <-ch; v != 3 {
+		t.Error(v)
+	}
+	if _, ok := <-ch; ok {
+		t.Error(ok)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestChannelBridge(t *testing.T) {
+	in := make(chan int)
+	out := NewChannelBridge(in, 3)
+	// The pump may hold the first item and a later one may be taken
+	// after the consumer starts, the rest gets evicted down to the
+	// last 3.
+	for i := 1; i <= 10; i++ {
+		in <- i
+	}
+	close(in)
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if n := len(got); n < 3 || n > 5 || got[n-1] != 10 || got[n-3] != 8 {
+		t.Error(got)
+	}
+}
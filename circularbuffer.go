@@ -1,19 +1,26 @@
 // Circular buffer implementation.
 // Features:
+//  - generic Buffer[T] storing items without boxing, CircularBuffer
+//    is a Buffer[interface{}]
 //  - no memory allocations during push/pop
 //  - nonblocking push (ie: evict old data)
 //  - pop item from the top
 //  - concurrent (through locking)
+//  - no hidden references: cells are cleared as soon as an item
+//    is consumed or evicted, so the buffer never keeps anything
+//    but its logical contents alive
 //
 // Semantics of eviction:
 //  - empty Evict callback - return evicted item when
 //    calling NBPush.
-//  - Evict callback present - return nil to NBPush
+//  - Evict callback present - return nil (zero value) to NBPush
 //    and call Evict() inline.
 package circularbuffer
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 type StackPusher interface {
@@ -25,101 +32,1158 @@ type StackGetter interface {
 	Pop() interface{}
 }
 
-type CircularBuffer struct {
+// Everything a CircularBuffer can do as a stack or a queue.
+type Stack interface {
+	StackPusher
+	StackGetter
+	Peek() (interface{}, bool)
+	Length() int
+	Empty() bool
+}
+
+var (
+	_ StackPusher = (*CircularBuffer)(nil)
+	_ StackGetter = (*CircularBuffer)(nil)
+	_ Stack       = (*CircularBuffer)(nil)
+)
+
+// What to do when pushing to a full buffer.
+type OverflowPolicy int
+
+const (
+	// Evict the oldest item to free the space for the new one.
+	// This is the default.
+	DropOldest OverflowPolicy = iota
+	// Keep the buffer intact and treat the new item as evicted.
+	DropNewest
+	// Wait for a free cell, as Push does.
+	Block
+	// Keep the buffer intact and fail the push with ErrFull.
+	Error
+	// Reservoir sampling: the n-th pushed item replaces a random
+	// item with probability capacity/n, otherwise it's evicted
+	// itself. The buffer then holds a uniform sample of everything
+	// pushed so far, best read with Snapshot. The count restarts on
+	// Reset.
+	Reservoir
+)
+
+type Buffer[T any] struct {
 	start  uint // idx of first used cell
 	pos    uint // idx of first unused cell
-	buffer []interface{}
-	size   uint
-	avail  chan bool // poor man's semaphore. len(avail) is always equal to (size + pos - start) % size
+	buffer []T  // len(buffer) is a power of two, at least size
+	size   uint // holds up to size-1 items
+	mask   uint // len(buffer)-1, to wrap indexes without division
 	lock   sync.Mutex
-	Evict  func(v interface{})
+
+	// Consumers blocked in Get/Pop wait here, it's signalled once
+	// per pushed item. Allocated on first wait.
+	items        *sync.Cond
+	batchWaiters int // GetN calls waiting for more than one item
+
+	// Goroutines asleep waiting for items or for space.
+	blockedConsumers int
+	blockedProducers int
+
+	// Called outside the lock with every evicted item. Assign it
+	// before the buffer is shared, use SetEvict afterwards.
+	Evict func(v T)
+	hooks Hooks[T]
+
+	config
+
+	dedupWindow uint
+	dedupKey    func(v T) string
+
+	less func(a, b T) bool // priority order for eviction, see WithLess
+
+	// Per-item metadata parallel to buffer, allocated on first use.
+	meta []interface{}
+	// Per-item deadlines parallel to buffer, zero for none. Allocated
+	// on first use.
+	expires []time.Time
+	// Conflation keys parallel to buffer, "" for none, and the cell
+	// holding each key. Allocated on first PushKeyed.
+	keys     []string
+	keyCells map[string]uint
+
+	// Items seen by the Reservoir policy.
+	sampled uint64
+
+	wm *watermarks // see Watermarks
+
+	// Size passed to the constructor, the floor for Compact and
+	// WithShrink, and since when the buffer is at most a quarter
+	// full (zero if it's not), for WithShrink.
+	initSize uint
+	lowSince time.Time
+
+	// Cumulative operation counters, protected by lock.
+	pushed  uint64
+	evicted uint64
+	gotten  uint64
+	popped  uint64
+	removed uint64
+
+	peak         uint      // highest number of items
+	lastEviction time.Time // zero if none
+
+	// Items handed out by ClaimBatch and not yet released.
+	claimed   int
+	processed uint64
+	idle      *sync.Cond // signalled when empty and nothing claimed
+
+	// Items handed out by Reserve, waiting for Ack or Nack.
+	reserved  map[Token]reservation[T]
+	lastToken Token
+	leaseDue   time.Time   // earliest lease deadline, zero if none
+	leaseTimer *time.Timer // fires at leaseDue, see armLeaseLocked
+
+	space *sync.Cond // signalled when a cell is freed, for Push
+
+	now      func() time.Time
+	rate     rateSample // throughput is measured from here
+	rateNext rateSample // becomes rate, see ThroughputPerSecond
+
+	recording bool
+	oplog     []Op
+
+	totalCost int64
+	costOf    func(v T) int64
+
+	codec *Codec[T] // see WithCodec
+	wal   *wal[T]   // see Recover
+
+	agg aggregator[T] // sees every item stored and removed
+
+	closed bool
+}
+
+// Buffer of arbitrary items, kept for compatibility. Use Buffer[T]
+// to avoid type assertions and boxing.
+type CircularBuffer = Buffer[interface{}]
+
+// Create CircularBuffer object with a prealocated buffer of a given
+// size, configured by the options. Same as NewCircularBuffer.
+func New(size uint, opts ...Option) *CircularBuffer {
+	return NewBuffer[interface{}](size, opts...)
 }
 
 // Create CircularBuffer object with a prealocated buffer of a given size.
-func NewCircularBuffer(size uint) *CircularBuffer {
-	return &CircularBuffer{
-		buffer: make([]interface{}, size),
-		size:   size,
-		avail:  make(chan bool, size),
+func NewCircularBuffer(size uint, opts ...Option) *CircularBuffer {
+	return NewBuffer[interface{}](size, opts...)
+}
+
+// Create Buffer object with a prealocated buffer of a given size. The
+// size must be at least 1.
+func NewBuffer[T any](size uint, opts ...Option) *Buffer[T] {
+	if size == 0 {
+		panic("circularbuffer: size must be at least 1")
+	}
+	n := ringSize(size)
+	b := &Buffer[T]{
+		buffer:   make([]T, n),
+		size:     size,
+		initSize: size,
+		mask:     n - 1,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(&b.config)
+	}
+	if b.evict != nil {
+		evict, ok := b.evict.(func(v T))
+		if !ok {
+			panic("circularbuffer: Evict callback doesn't match the item type")
+		}
+		b.Evict = evict
 	}
+	if b.config.hooks != nil {
+		hooks, ok := b.config.hooks.(Hooks[T])
+		if !ok {
+			panic("circularbuffer: Hooks don't match the item type")
+		}
+		b.hooks = hooks
+	}
+	if b.config.less != nil {
+		less, ok := b.config.less.(func(a, b T) bool)
+		if !ok {
+			panic("circularbuffer: Less comparator doesn't match the item type")
+		}
+		b.less = less
+	}
+	if b.costFn != nil {
+		costOf, ok := b.costFn.(func(v T) int64)
+		if !ok {
+			panic("circularbuffer: cost function doesn't match the item type")
+		}
+		b.costOf = costOf
+	}
+	if b.config.codec != nil {
+		codec, ok := b.config.codec.(Codec[T])
+		if !ok {
+			panic("circularbuffer: Codec doesn't match the item type")
+		}
+		b.codec = &codec
+	}
+	if b.clock != nil {
+		b.now = b.clock
+	}
+	b.resetRateLocked()
+	return b
+}
+
+// Create CircularBuffer object that silently drops pushed items equal
+// (by keyOf) to one of the most recent window items in the buffer.
+// keyOf is called with the lock held and must not use the buffer.
+func NewRecentDedupBuffer(size, window uint, keyOf func(interface{}) string) *CircularBuffer {
+	b := NewCircularBuffer(size)
+	b.dedupWindow = window
+	b.dedupKey = keyOf
+	return b
 }
 
 // Nonblocking push. If the Evict callback is not set returns the
-// evicted item (if any), otherwise nil.
-func (b *CircularBuffer) NBPush(v interface{}) interface{} {
-	var evictv interface{}
+// evicted item (if any), otherwise nil (zero value). When the buffer
+// is full the evicted item is either the oldest one or, with the
+// DropNewest policy, v itself. With the Block policy waits for a free
+// cell instead, with the Error policy returns v without pushing it
+// (use NBPushErr to get ErrFull). See ClosedPushMode for pushing to a
+// closed buffer. Use NBPushResult to tell an evicted zero value from
+// no eviction.
+func (b *Buffer[T]) NBPush(v T) T {
+	evictv, _, _, err := b.push(v, nil, 0)
+	if err != nil {
+		return v
+	}
+	return evictv
+}
+
+// Push all the items in order, taking the lock only once. Returns all
+// the evicted items, oldest first, or passes them to the Evict
+// callback if it is set. If the buffer is closed and refuses pushes
+// (ClosedPushError), PushAll stops there and the refused items are
+// returned after the evicted ones, Evict doesn't see them.
+func (b *Buffer[T]) PushAll(items []T) []T {
+	var keys []string
+	if b.dedupKey != nil {
+		keys = make([]string, len(items))
+		for i, v := range items {
+			keys[i] = b.dedupKey(v)
+		}
+	}
+
+	var evicted, stored, refused []T
 	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	for i, v := range items {
+		var key string
+		if keys != nil {
+			key = keys[i]
+		}
+		b.waitOverflowLocked()
+		var ok bool
+		var err error
+		evicted, ok, err = b.pushLocked(v, key, evicted)
+		if err == errPushPanic {
+			b.lock.Unlock()
+			panic("circularbuffer: push to closed buffer")
+		}
+		if err == ErrClosed {
+			refused = items[i:]
+			break
+		}
+		if err != nil {
+			evicted = append(evicted, v)
+		}
+		if ok && b.hooks.OnPush != nil {
+			stored = append(stored, v)
+		}
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
 
-	b.buffer[b.pos] = v
-	b.pos = (b.pos + 1) % b.size
-	if b.pos == b.start {
-		// Remove old item from the bottom of the stack to
-		// free the space for the new one. This doesn't change
-		// the length of the stack, so no need to touch avail.
-		evictv = b.buffer[b.start]
-		b.buffer[b.start] = nil
-		b.start = (b.start + 1) % b.size
-	} else {
-		select {
-		case b.avail <- true:
-		default:
-			panic("Sending to avail channel must never block")
+	reportExpired(expired, evict, &hooks)
+	hooks.evicted(evicted)
+	for _, v := range stored {
+		hooks.OnPush(v)
+	}
+	if evict != nil {
+		hooks.handOff(evict, evicted...)
+		evicted = nil
+	}
+	if refused != nil {
+		return append(evicted, refused...)
+	}
+	return evicted
+}
+
+// Move all the items from other to the end of this buffer, oldest
+// first. Overflow is handled as in PushAll, which returns the evicted
+// items. Other is drained first, under its own lock, so the two locks
+// are never held together; metadata is not carried over.
+func (b *Buffer[T]) AppendFrom(other *Buffer[T]) []T {
+	return b.PushAll(other.DrainAll())
+}
+
+// Push v with optional metadata and run the Evict callback. Returns
+// the evicted item (nil if it was passed to Evict), whether an item was
+// evicted and whether v was stored. If several items were evicted the
+// oldest one is returned. Returns ErrClosed if the buffer is closed
+// with the ClosedPushError mode.
+func (b *Buffer[T]) push(v T, meta interface{}, ttl time.Duration) (T, bool, bool, error) {
+	var key string
+	if b.dedupKey != nil {
+		key = b.dedupKey(v)
+	}
+	var evictbuf [1]T
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	b.waitOverflowLocked()
+	evicted, stored, err := b.pushLocked(v, key, evictbuf[:0])
+	if err == errPushPanic {
+		b.lock.Unlock()
+		panic("circularbuffer: push to closed buffer")
+	}
+	if stored && meta != nil {
+		if b.meta == nil {
+			b.meta = make([]interface{}, len(b.buffer))
 		}
+		b.meta[(b.pos-1)&b.mask] = meta
+	}
+	if stored && ttl > 0 {
+		b.setDeadlineLocked((b.pos-1)&b.mask, ttl)
 	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
 	b.lock.Unlock()
-	if evictv != nil && b.Evict != nil {
+
+	reportExpired(expired, evict, &hooks)
+	hooks.pushed(v, stored, evicted)
+	var zero T
+	if len(evicted) == 0 {
+		return zero, false, stored, err
+	}
+	if evict != nil {
 		// Outside the lock. User callback may in want to add
 		// an item to the stack.
-		b.Evict(evictv)
+		hooks.handOff(evict, evicted...)
+		return zero, true, stored, err
+	}
+	return evicted[0], true, stored, err
+}
+
+// Push v to a closed buffer, according to the ClosedPushMode. Must be
+// called with the lock held.
+func (b *Buffer[T]) closedPushLocked(v T, evicted []T) ([]T, bool, error) {
+	switch b.closedMode {
+	case ClosedPushPanic:
+		if b.noPanic {
+			return evicted, false, ErrClosed
+		}
+		return evicted, false, errPushPanic
+	case ClosedPushError:
+		return evicted, false, ErrClosed
+	}
+	b.pushed++
+	b.noteEvictionLocked()
+	return append(evicted, v), false, nil
+}
+
+// Push v, appending evicted items to the given slice. Returns the
+// slice and whether v was stored. On a closed buffer returns ErrClosed
+// or errPushPanic, according to the ClosedPushMode. Returns ErrFull if
+// the buffer is full and the policy is Error, or Block (the caller
+// should have waited with waitOverflowLocked). Must be called with the
+// lock held.
+func (b *Buffer[T]) pushLocked(v T, key string, evicted []T) ([]T, bool, error) {
+	if b.closed {
+		return b.closedPushLocked(v, evicted)
+	}
+	b.growLocked()
+	if (b.policy == Block || b.policy == Error) && b.costOf == nil && b.fullLocked() {
+		return evicted, false, ErrFull
+	}
+	b.pushed++
+	if b.recording {
+		b.oplog = append(b.oplog, Op{Kind: OpPush, Value: v})
+	}
+	if b.dedupKey != nil && b.isRecentDup(key) {
+		// Drop the duplicate. Nothing is evicted.
+		return evicted, false, nil
+	}
+	full := b.fullLocked()
+	if full && b.costOf != nil {
+		// Cost bounded buffer is limited by cost only.
+		b.resizeLocked(2 * b.size)
+		full = false
+	}
+	if b.policy == Reservoir {
+		b.sampled++
+		if full {
+			return b.sampleLocked(v, evicted)
+		}
+	}
+	if full && b.policy == DropNewest {
+		// Buffer is full, reject the new item.
+		b.noteEvictionLocked()
+		return append(evicted, v), false, nil
+	}
+
+	if full {
+		b.noteEvictionLocked()
+		if b.start == b.pos {
+			// No room at all, v goes right away.
+			return append(evicted, v), false, nil
+		}
+		if b.less != nil {
+			i, ok := b.lowestLocked(v)
+			if !ok {
+				// v has the lowest priority of all.
+				return append(evicted, v), false, nil
+			}
+			evicted = append(evicted, b.removeAtLocked(i))
+			b.setCell(b.pos, v)
+			b.pos = (b.pos + 1) & b.mask
+			return evicted, true, nil
+		}
+		// Remove old item from the bottom of the stack to
+		// free the space for the new one. This doesn't change
+		// the length of the stack, so no need to wake anyone.
+		evicted = append(evicted, b.buffer[b.start])
+		b.clearCell(b.start)
+		b.start = (b.start + 1) & b.mask
+		b.setCell(b.pos, v)
+		b.pos = (b.pos + 1) & b.mask
+		return evicted, true, nil
+	}
+
+	b.setCell(b.pos, v)
+	b.pos = (b.pos + 1) & b.mask
+	b.signalItemLocked()
+	b.lengthChangedLocked()
+
+	stored := true
+	if b.costOf != nil {
+		for b.totalCost > b.maxCost && b.start != b.pos {
+			if (b.start+1)&b.mask == b.pos {
+				// Evicting the item just pushed.
+				stored = false
+			}
+			evicted = append(evicted, b.evictOldestLocked())
+		}
+	}
+	return evicted, stored, nil
+}
+
+// With the Block policy wait until there is a free cell. Must be
+// called with the lock held.
+func (b *Buffer[T]) waitOverflowLocked() {
+	if b.policy == Block && b.costOf == nil {
+		b.waitSpaceLocked(context.Background())
+	}
+}
+
+// Remove the oldest item as evicted and return it. Must be called with
+// the lock held, buffer must not be empty.
+func (b *Buffer[T]) evictOldestLocked() T {
+	v := b.buffer[b.start]
+	b.clearCell(b.start)
+	b.start = (b.start + 1) & b.mask
+	b.noteEvictionLocked()
+	b.signalIdleLocked()
+	b.signalSpaceLocked()
+	b.lengthChangedLocked()
+	return v
+}
+
+// Change the size of the buffer, keeping the items in order. If the
+// buffer holds more items than fit in the new size the oldest ones are
+// evicted, and passed to the Evict callback or returned if it's not
+// set. Blocked consumers keep waiting on the resized buffer.
+func (b *Buffer[T]) Resize(size uint) []T {
+	if size == 0 {
+		panic("circularbuffer: size must be at least 1")
+	}
+
+	var evicted []T
+	b.lock.Lock()
+	for b.used() > size-1 {
+		evicted = append(evicted, b.evictOldestLocked())
+	}
+	b.resizeLocked(size)
+	if b.space != nil {
+		b.space.Broadcast()
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	hooks.evicted(evicted)
+	if evict != nil {
+		hooks.handOff(evict, evicted...)
 		return nil
 	}
-	return evictv
+	return evicted
+}
+
+// Move the items to a new backing array of the given size, which must
+// be large enough to hold them. Must be called with the lock held.
+func (b *Buffer[T]) resizeLocked(size uint) {
+	used := b.used()
+	n := ringSize(size)
+	buffer := make([]T, n)
+	var meta []interface{}
+	if b.meta != nil {
+		meta = make([]interface{}, n)
+	}
+	var expires []time.Time
+	if b.expires != nil {
+		expires = make([]time.Time, n)
+	}
+	var keys []string
+	if b.keys != nil {
+		keys = make([]string, n)
+	}
+	for i := uint(0); i < used; i++ {
+		buffer[i] = b.buffer[(b.start+i)&b.mask]
+		if meta != nil {
+			meta[i] = b.meta[(b.start+i)&b.mask]
+		}
+		if expires != nil {
+			expires[i] = b.expires[(b.start+i)&b.mask]
+		}
+		if keys != nil {
+			keys[i] = b.keys[(b.start+i)&b.mask]
+			if keys[i] != "" {
+				b.keyCells[keys[i]] = i
+			}
+		}
+	}
+
+	b.buffer, b.meta, b.expires, b.keys = buffer, meta, expires, keys
+	b.size, b.mask, b.start, b.pos = size, n-1, 0, used
+	if b.wal != nil && b.wal.err == nil {
+		// Cell indexes changed, start a new log.
+		b.wal.err = b.wal.rebuild(b)
+	}
+}
+
+// Number of used cells. Must be called with the lock held.
+func (b *Buffer[T]) used() uint {
+	return (b.pos - b.start) & b.mask
+}
+
+// Store v in the unused cell i, accounting for it. Must be called
+// with the lock held.
+func (b *Buffer[T]) setCell(i uint, v T) {
+	b.buffer[i] = v
+	if b.costOf != nil {
+		b.totalCost += b.costOf(v)
+	}
+	if b.agg != nil {
+		b.agg.add(v)
+	}
+	b.stampLocked(i)
+	if b.wal != nil {
+		b.wal.log(b, walSet, i, 0, &v)
+	}
+}
+
+// Drop references held by a cell, and its accounting. Must be called
+// with the lock held.
+func (b *Buffer[T]) clearCell(i uint) {
+	if b.costOf != nil {
+		b.totalCost -= b.costOf(b.buffer[i])
+	}
+	if b.agg != nil {
+		b.agg.remove(b.buffer[i])
+	}
+	var zero T
+	b.buffer[i] = zero
+	if b.meta != nil {
+		b.meta[i] = nil
+	}
+	if b.expires != nil {
+		b.expires[i] = time.Time{}
+	}
+	b.dropKey(i)
+	if b.wal != nil {
+		b.wal.log(b, walClear, i, 0, nil)
+	}
+}
+
+// Move the item in cell src, with everything attached to it, to the
+// unused cell dst, leaving src unused. Must be called with the lock
+// held.
+func (b *Buffer[T]) moveCell(dst, src uint) {
+	var zero T
+	b.buffer[dst], b.buffer[src] = b.buffer[src], zero
+	if b.meta != nil {
+		b.meta[dst], b.meta[src] = b.meta[src], nil
+	}
+	if b.expires != nil {
+		b.expires[dst], b.expires[src] = b.expires[src], time.Time{}
+	}
+	if b.keys != nil && b.keys[src] != "" {
+		b.keys[dst], b.keys[src] = b.keys[src], ""
+		b.keyCells[b.keys[dst]] = dst
+	}
+	if b.wal != nil {
+		b.wal.log(b, walMove, dst, src, nil)
+	}
+}
+
+// Is an item with the given key among the most recent dedupWindow
+// items? Must be called with the lock held.
+func (b *Buffer[T]) isRecentDup(key string) bool {
+	used := b.used()
+	for i := uint(1); i <= b.dedupWindow && i <= used; i++ {
+		if b.dedupKey(b.buffer[(b.pos-i)&b.mask]) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Change the overflow policy. Takes effect for all subsequent pushes,
+// items already in the buffer are not affected.
+func (b *Buffer[T]) SetPolicy(p OverflowPolicy) {
+	b.lock.Lock()
+	b.policy = p
+	b.lock.Unlock()
+}
+
+// Replace the Evict callback, nil to return evicted items instead.
+// Pushes that already evicted items under the lock still pass them to
+// the old callback, later pushes use the new one.
+func (b *Buffer[T]) SetEvict(fn func(v T)) {
+	b.lock.Lock()
+	b.Evict = fn
+	b.lock.Unlock()
+}
+
+// Set the Evict callback. Its item type must match the buffer's, use
+// func(interface{}) for CircularBuffer.
+func WithEvict[T any](fn func(v T)) Option {
+	return func(c *config) {
+		c.evict = fn
+	}
+}
+
+// Set the overflow policy, DropOldest by default.
+func WithOverflowPolicy(p OverflowPolicy) Option {
+	return func(c *config) {
+		c.policy = p
+	}
 }
 
 // Get an item from the beginning of the queue (oldest), blocking.
-func (b *CircularBuffer) Get() interface{} {
-	_ = <-b.avail
+// Returns the zero value once the buffer is closed and drained.
+func (b *Buffer[T]) Get() T {
+	v, _ := b.take(context.Background(), false)
+	return v
+}
 
+// Blocking pop an item from the end of the queue (newest), blocking.
+// Returns the zero value once the buffer is closed and drained.
+func (b *Buffer[T]) Pop() T {
+	v, _ := b.take(context.Background(), true)
+	return v
+}
+
+// Get an item from the beginning of the queue (oldest), blocking until
+// one is available or ctx is done, in which case returns ctx.Err().
+// Returns ErrClosed once the buffer is closed and drained.
+func (b *Buffer[T]) GetContext(ctx context.Context) (T, error) {
+	return b.take(ctx, false)
+}
+
+// Pop an item from the end of the queue (newest), blocking until one
+// is available or ctx is done, in which case returns ctx.Err().
+// Returns ErrClosed once the buffer is closed and drained.
+func (b *Buffer[T]) PopContext(ctx context.Context) (T, error) {
+	return b.take(ctx, true)
+}
+
+// Get an item from the beginning of the queue (oldest), waiting up to
+// d for one to become available. Returns ErrTimeout if none did, or
+// ErrClosed once the buffer is closed and drained.
+func (b *Buffer[T]) GetTimeout(d time.Duration) (T, error) {
+	return b.takeTimeout(d, false)
+}
+
+// Pop an item from the end of the queue (newest), waiting up to d for
+// one to become available. Returns ErrTimeout if none did, or
+// ErrClosed once the buffer is closed and drained.
+func (b *Buffer[T]) PopTimeout(d time.Duration) (T, error) {
+	return b.takeTimeout(d, true)
+}
+
+func (b *Buffer[T]) takeTimeout(d time.Duration, newest bool) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	v, err := b.take(ctx, newest)
+	if err == context.DeadlineExceeded {
+		err = ErrTimeout
+	}
+	return v, err
+}
+
+// Get an item from the beginning of the queue (oldest) without
+// blocking. ok is false if the buffer is empty.
+func (b *Buffer[T]) TryGet() (v T, ok bool) {
+	r := b.GetResult()
+	return r.Value, r.OK
+}
+
+// Pop an item from the end of the queue (newest) without blocking. ok
+// is false if the buffer is empty.
+func (b *Buffer[T]) TryPop() (v T, ok bool) {
+	r := b.PopResult()
+	return r.Value, r.OK
+}
+
+// Same as TryGet, but returns ErrEmpty if the buffer is empty.
+func (b *Buffer[T]) GetErr() (T, error) {
+	r := b.GetResult()
+	if !r.OK {
+		return r.Value, ErrEmpty
+	}
+	return r.Value, nil
+}
+
+// Same as TryPop, but returns ErrEmpty if the buffer is empty.
+func (b *Buffer[T]) PopErr() (T, error) {
+	r := b.PopResult()
+	if !r.OK {
+		return r.Value, ErrEmpty
+	}
+	return r.Value, nil
+}
+
+// Wait until at least min items are available, or maxWait elapses,
+// and then remove up to max oldest items under a single lock
+// acquisition. After a timeout, or once the buffer is closed, returns
+// whatever is available, possibly nothing.
+func (b *Buffer[T]) GetN(min, max int, maxWait time.Duration) []T {
+	if min > max {
+		min = max
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), maxWait)
+	defer cancel()
+
+	var items, expired []T
 	b.lock.Lock()
-	defer b.lock.Unlock()
+	b.waitItemsLocked(ctx, min)
+	for len(items) < max {
+		v, ok := b.takeLiveLocked(false, &expired)
+		if !ok {
+			break
+		}
+		items = append(items, v)
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.taken(false, items...)
+	return items
+}
+
+// Remove and return all the items, oldest first, under a single lock
+// acquisition. See Drain for an iterator that consumes items one by
+// one.
+func (b *Buffer[T]) DrainAll() []T {
+	var expired []T
+	b.lock.Lock()
+	items := make([]T, 0, b.used())
+	for {
+		v, ok := b.takeLiveLocked(false, &expired)
+		if !ok {
+			break
+		}
+		items = append(items, v)
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.taken(false, items...)
+	return items
+}
 
+// Discard all the items and return the buffer to its initial state,
+// for reuse. Counters are zeroed and a closed buffer is reopened,
+// configuration and callbacks are kept. If evict is true the discarded
+// items are evicted, oldest first: OnEvict, EvictedChan and the Evict
+// callback see them. The OnRecycle hook sees them either way.
+func (b *Buffer[T]) Reset(evict bool) {
+	var items []T
+	b.lock.Lock()
+	evictfn, hooks := b.Evict, b.hooks
+	if !evict {
+		evictfn = nil
+	}
+	for b.start != b.pos {
+		if evict || hooks.OnRecycle != nil {
+			items = append(items, b.buffer[b.start])
+		}
+		b.clearCell(b.start)
+		b.start = (b.start + 1) & b.mask
+	}
+	b.start, b.pos = 0, 0
+	b.pushed, b.evicted, b.gotten, b.popped = 0, 0, 0, 0
+	b.removed, b.processed, b.sampled = 0, 0, 0
+	b.peak, b.lastEviction = 0, time.Time{}
+	b.resetRateLocked()
+	b.oplog = nil
+	b.closed = false
+	b.signalIdleLocked()
+	if b.space != nil {
+		b.space.Broadcast()
+	}
+	b.lengthChangedLocked()
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	if evict {
+		hooks.evicted(items)
+	}
+	hooks.handOff(evictfn, items...)
+}
+
+// Wait for an item and remove the newest or the oldest one.
+func (b *Buffer[T]) take(ctx context.Context, newest bool) (T, error) {
+	var expired, evicted []T
+	var v T
+	b.lock.Lock()
+	for {
+		evicted = b.requeueLapsedLocked(evicted)
+		if err := b.waitItemsLocked(ctx, 1); err != nil {
+			evict, hooks := b.Evict, b.hooks
+			b.lock.Unlock()
+			reportExpired(expired, evict, &hooks)
+			hooks.evicted(evicted)
+			hooks.handOff(evict, evicted...)
+			var zero T
+			return zero, err
+		}
+		var ok bool
+		if v, ok = b.takeLiveLocked(newest, &expired); ok {
+			break
+		}
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.evicted(evicted)
+	hooks.handOff(evict, evicted...)
+	hooks.taken(newest, v)
+	return v, nil
+}
+
+// Wait until the buffer holds at least n items. Returns ErrClosed if
+// it's closed with fewer items, or ctx.Err() if ctx is done first.
+// Must be called with the lock held.
+func (b *Buffer[T]) waitItemsLocked(ctx context.Context, n int) error {
+	if int(b.used()) >= n {
+		return nil
+	}
+	if b.items == nil {
+		b.items = sync.NewCond(&b.lock)
+	}
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			b.lock.Lock()
+			b.items.Broadcast()
+			b.lock.Unlock()
+		})
+		defer stop()
+	}
+	if n > 1 {
+		// Pushes wake up only one waiter, which must be able to
+		// take the item. Ask them to wake up everyone instead.
+		b.batchWaiters++
+		defer func() { b.batchWaiters-- }()
+	}
+
+	for int(b.used()) < n {
+		if b.closed {
+			return ErrClosed
+		}
+		if err := ctx.Err(); err != nil {
+			if b.start != b.pos {
+				// We may have taken a wakeup meant for
+				// another consumer, pass it on.
+				b.signalItemLocked()
+			}
+			return err
+		}
+		b.blockedConsumers++
+		b.items.Wait()
+		b.blockedConsumers--
+	}
+	return nil
+}
+
+// Wake up a consumer blocked waiting for items. Must be called with
+// the lock held, after storing an item.
+func (b *Buffer[T]) signalItemLocked() {
+	if b.items == nil {
+		return
+	}
+	if b.batchWaiters > 0 {
+		b.items.Broadcast()
+	} else {
+		b.items.Signal()
+	}
+}
+
+// Remove the oldest item. Must be called with the lock held, buffer
+// must not be empty.
+func (b *Buffer[T]) getLocked() T {
 	if b.start == b.pos {
 		panic("Trying to get from empty buffer")
 	}
 
 	v := b.buffer[b.start]
-	b.buffer[b.pos] = nil
-	b.start = (b.start + 1) % b.size
+	b.clearCell(b.start)
+	b.start = (b.start + 1) & b.mask
+	b.gotten++
+	if b.recording {
+		b.oplog = append(b.oplog, Op{Kind: OpGet, Value: v})
+	}
+	b.signalIdleLocked()
+	b.signalSpaceLocked()
+	b.lengthChangedLocked()
+	b.shrinkLocked()
+	b.checkInvariants()
 
 	return v
 }
 
-// Blocking pop an item from the end of the queue (newest), blocking.
-func (b *CircularBuffer) Pop() interface{} {
-	_ = <-b.avail
+// Remove the newest item. Must be called with the lock held, buffer
+// must not be empty.
+func (b *Buffer[T]) popLocked() T {
+	if b.start == b.pos {
+		panic("Can't pop from empty buffer")
+	}
 
+	b.pos = (b.pos - 1) & b.mask
+	v := b.buffer[b.pos]
+	b.clearCell(b.pos)
+	b.popped++
+	if b.recording {
+		b.oplog = append(b.oplog, Op{Kind: OpPop, Value: v})
+	}
+	b.signalIdleLocked()
+	b.signalSpaceLocked()
+	b.lengthChangedLocked()
+	b.shrinkLocked()
+	b.checkInvariants()
+
+	return v
+}
+
+// Get the oldest item without removing it, same as PeekOldest.
+func (b *Buffer[T]) Peek() (v T, ok bool) {
+	return b.PeekOldest()
+}
+
+// Get the oldest item without removing it. ok is false if the buffer
+// is empty.
+func (b *Buffer[T]) PeekOldest() (v T, ok bool) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
 	if b.start == b.pos {
-		panic("Can't pop from empty buffer")
+		return v, false
 	}
+	return b.buffer[b.start], true
+}
 
-	b.pos = (b.size + b.pos - 1) % b.size
-	v := b.buffer[b.pos]
-	b.buffer[b.pos] = nil
+// Get the newest item without removing it. ok is false if the buffer
+// is empty.
+func (b *Buffer[T]) PeekNewest() (v T, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
 
-	return v
+	if b.start == b.pos {
+		return v, false
+	}
+	return b.buffer[(b.pos-1)&b.mask], true
+}
+
+// Get the i-th oldest item without removing it, At(0) being the
+// oldest. Negative indexes count from the newest end, At(-1) being the
+// newest. ok is false if i is out of range.
+func (b *Buffer[T]) At(i int) (v T, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	used := int(b.used())
+	if i < 0 {
+		i += used
+	}
+	if i < 0 || i >= used {
+		return v, false
+	}
+	return b.buffer[(b.start+uint(i))&b.mask], true
+}
+
+// Copy of the items, oldest first, taken consistently under the lock.
+// Nothing is removed.
+func (b *Buffer[T]) Snapshot() []T {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	items := make([]T, b.used())
+	b.copyLocked(items)
+	return items
+}
+
+// Independent copy of the buffer, taken consistently under the lock:
+// same size, items, metadata and configuration, including the Evict
+// callback and hooks. Counters and the operation log are not copied
+// and the clone is open even if the buffer is closed.
+func (b *Buffer[T]) Clone() *Buffer[T] {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c := b.emptyCloneLocked()
+	c.totalCost = b.totalCost
+
+	used := b.used()
+	b.copyLocked(c.buffer)
+	if b.meta != nil {
+		c.meta = make([]interface{}, len(c.buffer))
+		for i := uint(0); i < used; i++ {
+			c.meta[i] = b.meta[(b.start+i)&b.mask]
+		}
+	}
+	if b.expires != nil {
+		c.expires = make([]time.Time, len(c.buffer))
+		for i := uint(0); i < used; i++ {
+			c.expires[i] = b.expires[(b.start+i)&b.mask]
+		}
+	}
+	if b.keys != nil {
+		c.keys = make([]string, len(c.buffer))
+		c.keyCells = make(map[string]uint, len(b.keyCells))
+		for i := uint(0); i < used; i++ {
+			c.keys[i] = b.keys[(b.start+i)&b.mask]
+			if c.keys[i] != "" {
+				c.keyCells[c.keys[i]] = i
+			}
+		}
+	}
+	c.pos = used
+	c.peak = used
+	c.checkInvariants()
+	return c
+}
+
+// New empty buffer with the same size and configuration, see Clone.
+// Must be called with the lock held.
+func (b *Buffer[T]) emptyCloneLocked() *Buffer[T] {
+	c := NewBuffer[T](b.size)
+	c.config = b.config
+	c.initSize = b.initSize
+	c.Evict, c.hooks = b.Evict, b.hooks
+	c.hooks.spill = nil
+	c.dedupWindow, c.dedupKey = b.dedupWindow, b.dedupKey
+	c.costOf = b.costOf
+	c.less, c.codec = b.less, b.codec
+	c.recording = b.recording
+	c.now = b.now
+	c.resetRateLocked()
+	return c
+}
+
+// Copy up to len(dst) oldest items to dst without removing them.
+// Returns the number of items copied. Doesn't allocate, unlike
+// Snapshot.
+func (b *Buffer[T]) CopyTo(dst []T) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.copyLocked(dst)
+}
+
+// Copy up to len(dst) oldest items to dst. Returns the number of items
+// copied. Must be called with the lock held.
+func (b *Buffer[T]) copyLocked(dst []T) int {
+	if b.start <= b.pos {
+		return copy(dst, b.buffer[b.start:b.pos])
+	}
+	n := copy(dst, b.buffer[b.start:])
+	return n + copy(dst[n:], b.buffer[:b.pos])
 }
 
 // Is the buffer empty?
-func (b *CircularBuffer) Empty() bool {
-	// b.avail is a channel, no need for a lock
-	return len(b.avail) == 0
+func (b *Buffer[T]) Empty() bool {
+	return b.Length() == 0
 }
 
 // Length of the buffer
-func (b *CircularBuffer) Length() int {
-	// b.avail is a channel, no need for a lock
-	return len(b.avail)
+func (b *Buffer[T]) Length() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return int(b.used())
+}
+
+// Maximum number of items the buffer can hold.
+func (b *Buffer[T]) Cap() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.capLocked()
+}
+
+// Same as Cap.
+func (b *Buffer[T]) Capacity() int {
+	return b.Cap()
+}
+
+// One cell is always left unused to tell full from empty.
+func (b *Buffer[T]) capLocked() int {
+	return int(b.size) - 1
+}
+
+// Number of items that can be pushed before eviction begins.
+func (b *Buffer[T]) Free() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.capLocked() - int(b.used())
+}
+
+// Will the next push evict an item?
+func (b *Buffer[T]) Full() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.fullLocked()
+}
+
+func (b *Buffer[T]) fullLocked() bool {
+	return b.used() == b.size-1 && b.size >= b.maxSize
+}
+
+// Number of cells for a buffer of the given size: the next power of
+// two, so that indexes wrap with a mask. Panics if there's none.
+func ringSize(size uint) uint {
+	if size > ^uint(0)>>1+1 {
+		panic("circularbuffer: size too large")
+	}
+	n := uint(1)
+	for n < size {
+		n <<= 1
+	}
+	return n
 }
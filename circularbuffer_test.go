@@ -1,20 +1,20 @@
 package circularbuffer
 
 import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
-func (b *CircularBuffer) verifyIsEmpty() bool {
+func (b *Buffer[T]) verifyIsEmpty() bool {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	e := len(b.avail) == 0
-	if e {
-		if b.pos != b.start {
-			panic("desychronized state")
-		}
-	}
-	return e
+	return b.start == b.pos && b.invariantsLocked() == nil
 }
 
 func TestSyncGet(t *testing.T) {
@@ -159,3 +159,1051 @@ func TestSyncOverflowEvictCallback(t *testing.T) {
 		t.Error("not empty")
 	}
 }
+
+func TestSetPolicy(t *testing.T) {
+	c := NewCircularBuffer(4) // up to 3 items in the buffer
+
+	var wg sync.WaitGroup
+	var evicted int32
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if v := c.NBPush(g*100 + i); v != nil {
+					atomic.AddInt32(&evicted, 1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// DropOldest: everything but the last 3 items got evicted.
+	if evicted != 400-3 {
+		t.Error(evicted)
+	}
+
+	// Flip the policy while producers are running.
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.NBPush(-1)
+				if g == 0 && i == 50 {
+					c.SetPolicy(DropNewest)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// DropNewest: new items are rejected and returned back.
+	for i := 0; i < 100; i++ {
+		if v := c.NBPush(1000 + i); v != 1000+i {
+			t.Error(v)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if v := c.Get().(int); v >= 1000 {
+			t.Error(v)
+		}
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	var evicted, pushed []interface{}
+	c := New(3,
+		WithOverflowPolicy(DropNewest),
+		WithEvict(func(v interface{}) {
+			evicted = append(evicted, v)
+		}),
+		WithHooks(Hooks[interface{}]{OnPush: func(v interface{}) {
+			pushed = append(pushed, v)
+		}}),
+		WithClosedPushMode(ClosedPushError),
+	)
+
+	for i := 0; i < 3; i++ {
+		if v := c.NBPush(i); v != nil {
+			t.Error(v)
+		}
+	}
+	if len(evicted) != 1 || evicted[0] != 2 || len(pushed) != 2 {
+		t.Error(evicted, pushed)
+	}
+	c.Close()
+	if _, err := c.NBPushErr(3); err != ErrClosed {
+		t.Error(err)
+	}
+	c.Get()
+	c.Get()
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestOverflowPolicyOption(t *testing.T) {
+	// Error: the full buffer is left intact.
+	c := NewBuffer[int](3, WithOverflowPolicy(Error))
+	c.NBPush(1)
+	c.NBPush(2)
+	if v := c.NBPush(3); v != 3 {
+		t.Error(v)
+	}
+	if _, err := c.NBPushErr(3); err != ErrFull {
+		t.Error(err)
+	}
+	if s := c.Stats(); s.Pushed != 2 || s.Evicted != 0 {
+		t.Error(s)
+	}
+	if items := c.PushAll([]int{3, 4}); len(items) != 2 {
+		t.Error(items)
+	}
+	if v := c.Get(); v != 1 {
+		t.Error(v)
+	}
+	if v := c.Get(); v != 2 {
+		t.Error(v)
+	}
+
+	// Block: NBPush waits for the consumer.
+	c = NewBuffer[int](3, WithOverflowPolicy(Block))
+	c.NBPush(1)
+	c.NBPush(2)
+	done := make(chan int)
+	go func() {
+		done <- c.NBPush(3)
+	}()
+	select {
+	case <-done:
+		t.Error("pushed to a full buffer")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if v := c.Get(); v != 1 {
+		t.Error(v)
+	}
+	if v := <-done; v != 0 {
+		t.Error(v)
+	}
+	if v := c.Get(); v != 2 {
+		t.Error(v)
+	}
+	if v := c.Get(); v != 3 {
+		t.Error(v)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestSetEvict(t *testing.T) {
+	var first, second []int
+	var mu sync.Mutex
+	c := NewBuffer[int](3, WithEvict(func(v int) {
+		mu.Lock()
+		first = append(first, v)
+		mu.Unlock()
+	}))
+	for i := 0; i < 3; i++ {
+		c.NBPush(i)
+	}
+
+	// Swap the callback while producers are running.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if v := c.NBPush(i); v != 0 {
+					t.Error(v)
+				}
+				if g == 0 && i == 50 {
+					c.SetEvict(func(v int) {
+						mu.Lock()
+						second = append(second, v)
+						mu.Unlock()
+					})
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if len(first) == 0 || len(first)+len(second) != 401 {
+		t.Error(len(first), len(second))
+	}
+
+	// Back to returning evicted items.
+	c.SetEvict(nil)
+	if v := c.NBPush(7); v == 0 {
+		t.Error(v)
+	}
+	c.Get()
+	c.Get()
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	NewBuffer[string](3, WithEvict(func(v int) {}))
+}
+
+func TestNewZeroSize(t *testing.T) {
+	for _, f := range []func(){
+		func() { NewBuffer[int](0) },
+		func() { NewCircularBuffer(0) },
+		func() { NewFromSlice([]int{1}, 0) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != "circularbuffer: size must be at least 1" {
+					t.Error(r)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestRecentDedup(t *testing.T) {
+	c := NewRecentDedupBuffer(10, 1, func(v interface{}) string {
+		return v.(string)
+	})
+
+	for _, v := range []string{"A", "A", "B", "A"} {
+		if e := c.NBPush(v); e != nil {
+			t.Error(e)
+		}
+	}
+
+	for _, v := range []string{"A", "B", "A"} {
+		if w := c.Get().(string); w != v {
+			t.Error(w)
+		}
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestFree(t *testing.T) {
+	c := NewCircularBuffer(5)
+
+	check := func() {
+		if c.Free()+c.Length() != c.Cap() {
+			t.Error(c.Free(), c.Length(), c.Cap())
+		}
+	}
+
+	if c.Cap() != 4 || c.Free() != 4 {
+		t.Error(c.Cap(), c.Free())
+	}
+	for i := 0; i < 6; i++ {
+		c.NBPush(i)
+		check()
+	}
+	if c.Free() != 0 {
+		t.Error(c.Free())
+	}
+	c.Get()
+	check()
+	c.Pop()
+	check()
+	if c.Free() != 2 {
+		t.Error(c.Free())
+	}
+	c.Get()
+	c.Get()
+	check()
+	if c.Free() != 4 {
+		t.Error(c.Free())
+	}
+}
+
+func TestReleasesReferences(t *testing.T) {
+	type large struct {
+		payload [1 << 20]byte
+	}
+
+	var finalized int32
+	newLarge := func() *large {
+		v := &large{}
+		runtime.SetFinalizer(v, func(*large) {
+			atomic.AddInt32(&finalized, 1)
+		})
+		return v
+	}
+
+	c := NewCircularBuffer(4)
+	for i := 0; i < 6; i++ {
+		c.NBPush(newLarge()) // evicts 3
+	}
+	c.Get()
+	c.Pop()
+
+	c.Evict = func(v interface{}) {}
+	c.NBPush(newLarge())
+	c.NBPush(newLarge())
+	c.NBPush(newLarge()) // evicts 1
+	c.Get()
+
+	// 9 items were created, 2 are left in the buffer.
+	for i := 0; i < 100 && atomic.LoadInt32(&finalized) < 7; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&finalized); n != 7 {
+		t.Error(n)
+	}
+	runtime.KeepAlive(c)
+}
+
+func TestStackInterface(t *testing.T) {
+	var s Stack = NewCircularBuffer(4)
+
+	if !s.Empty() || s.Length() != 0 {
+		t.Error(s.Length())
+	}
+	if v, ok := s.Peek(); ok {
+		t.Error(v)
+	}
+
+	for i := 0; i < 4; i++ {
+		s.NBPush(i)
+	}
+	if s.Empty() || s.Length() != 3 {
+		t.Error(s.Length())
+	}
+	if v, ok := s.Peek(); !ok || v != 1 {
+		t.Error(v, ok)
+	}
+	if v := s.Pop(); v != 3 {
+		t.Error(v)
+	}
+	if v := s.Get(); v != 1 {
+		t.Error(v)
+	}
+	if v, ok := s.Peek(); !ok || v != 2 {
+		t.Error(v, ok)
+	}
+	if v := s.Get(); v != 2 {
+		t.Error(v)
+	}
+	if !s.Empty() {
+		t.Error(s.Length())
+	}
+}
+
+// What benchmarkProducerConsumer needs from a buffer.
+type benchQueue interface {
+	NBPush(interface{}) interface{}
+	Get() interface{}
+}
+
+func benchmarkProducerConsumer(b *testing.B, c benchQueue, producers, consumers int) {
+
+	var wg sync.WaitGroup
+	var running int32 = int32(consumers)
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer atomic.AddInt32(&running, -1)
+			for c.Get() != nil {
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	var pwg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		pwg.Add(1)
+		go func(n int) {
+			defer pwg.Done()
+			for j := 0; j < n; j++ {
+				c.NBPush(j + 1)
+			}
+		}(b.N / producers)
+	}
+	pwg.Wait()
+
+	// nil tells a consumer to stop. Keep pushing, as they may get
+	// evicted.
+	for atomic.LoadInt32(&running) > 0 {
+		c.NBPush(nil)
+		runtime.Gosched()
+	}
+	wg.Wait()
+	b.StopTimer()
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+}
+
+func BenchmarkProducerConsumer(b *testing.B) {
+	for _, p := range []int{1, 4, 16} {
+		for _, c := range []int{1, 4} {
+			for _, size := range []uint{16, 1024} {
+				name := fmt.Sprintf("p%d-c%d-size%d", p, c, size)
+				b.Run(name, func(b *testing.B) {
+					benchmarkProducerConsumer(b, NewCircularBuffer(size), p, c)
+				})
+			}
+		}
+	}
+}
+
+func TestConsumerProgressUnderLoad(t *testing.T) {
+	c := NewCircularBuffer(64)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					c.NBPush(1)
+				}
+			}
+		}()
+	}
+
+	// A lone consumer must keep getting items, with no long
+	// stalls, while the producers hammer the buffer.
+	got := 0
+	var maxGap time.Duration
+	last := time.Now()
+	deadline := last.Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		c.Get()
+		now := time.Now()
+		if now.Sub(last) > maxGap {
+			maxGap = now.Sub(last)
+		}
+		last = now
+		got++
+	}
+	close(stop)
+	wg.Wait()
+
+	// Thresholds are loose: with few CPUs the consumer waits for the
+	// scheduler to cycle through all the producers.
+	if got == 0 || maxGap > time.Second {
+		t.Error(got, maxGap)
+	}
+}
+
+func TestGenericBuffer(t *testing.T) {
+	c := NewBuffer[int](4) // up to 3 items in the buffer
+
+	for i := 0; i < 3; i++ {
+		if r := c.NBPushResult(i); !r.OK || r.Evicted {
+			t.Error(r)
+		}
+	}
+	// Evicting 0 returns 0, NBPushResult tells it from no eviction.
+	if r := c.NBPushResult(3); !r.OK || !r.Evicted || r.Value != 0 {
+		t.Error(r)
+	}
+	if v := c.NBPush(4); v != 1 {
+		t.Error(v)
+	}
+
+	if v := c.Get(); v != 2 {
+		t.Error(v)
+	}
+	if v := c.Pop(); v != 4 {
+		t.Error(v)
+	}
+	if v, ok := c.Peek(); !ok || v != 3 {
+		t.Error(v, ok)
+	}
+	if v := c.Get(); v != 3 {
+		t.Error(v)
+	}
+	if r := c.GetResult(); r.OK {
+		t.Error(r)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func BenchmarkPushGet(b *testing.B) {
+	c := NewCircularBuffer(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.NBPush(i)
+		c.Get()
+	}
+}
+
+func BenchmarkPushGetGeneric(b *testing.B) {
+	c := NewBuffer[int](1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.NBPush(i)
+		c.Get()
+	}
+}
+
+func TestGetContext(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if v, err := c.GetContext(ctx); v != nil || err != context.DeadlineExceeded {
+		t.Error(v, err)
+	}
+	if v, err := c.PopContext(ctx); v != nil || err != context.DeadlineExceeded {
+		t.Error(v, err)
+	}
+
+	c.NBPush(1)
+	c.NBPush(2)
+	if v, err := c.GetContext(context.Background()); v != 1 || err != nil {
+		t.Error(v, err)
+	}
+	if v, err := c.PopContext(context.Background()); v != 2 || err != nil {
+		t.Error(v, err)
+	}
+
+	// Cancel a blocked consumer.
+	ctx, cancel = context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := c.GetContext(ctx)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error(err)
+	}
+
+	// A cancelled consumer doesn't take anything.
+	c.NBPush(3)
+	if v := c.Get(); v != 3 {
+		t.Error(v)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestTryGetTryPop(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	if v, ok := c.TryGet(); ok || v != nil {
+		t.Error(v, ok)
+	}
+	if v, ok := c.TryPop(); ok || v != nil {
+		t.Error(v, ok)
+	}
+
+	c.NBPush(1)
+	c.NBPush(2)
+	c.NBPush(3)
+	if v, ok := c.TryGet(); !ok || v != 1 {
+		t.Error(v, ok)
+	}
+	if v, ok := c.TryPop(); !ok || v != 3 {
+		t.Error(v, ok)
+	}
+
+	// Many consumers racing for a few items, each item is taken
+	// exactly once.
+	for i := 0; i < 99; i++ {
+		c.NBPush(i)
+	}
+	var wg sync.WaitGroup
+	var taken int32
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := c.TryGet(); !ok {
+					return
+				}
+				atomic.AddInt32(&taken, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if taken != 9 {
+		t.Error(taken)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestGetTimeout(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	start := time.Now()
+	if v, err := c.GetTimeout(10 * time.Millisecond); v != nil || err != ErrTimeout {
+		t.Error(v, err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error(time.Since(start))
+	}
+	if v, err := c.PopTimeout(time.Millisecond); v != nil || err != ErrTimeout {
+		t.Error(v, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.NBPush(1)
+		c.NBPush(2)
+	}()
+	if v, err := c.GetTimeout(time.Second); v != 1 || err != nil {
+		t.Error(v, err)
+	}
+	if v, err := c.PopTimeout(time.Second); v != 2 || err != nil {
+		t.Error(v, err)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	c := NewCircularBuffer(4)
+
+	if v, ok := c.PeekOldest(); ok || v != nil {
+		t.Error(v, ok)
+	}
+	if v, ok := c.PeekNewest(); ok || v != nil {
+		t.Error(v, ok)
+	}
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	for i := 0; i < 2; i++ {
+		if v, ok := c.PeekOldest(); !ok || v != 2 {
+			t.Error(v, ok)
+		}
+		if v, ok := c.PeekNewest(); !ok || v != 4 {
+			t.Error(v, ok)
+		}
+	}
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+
+	c.Pop()
+	if v, ok := c.PeekNewest(); !ok || v != 3 {
+		t.Error(v, ok)
+	}
+	c.Get()
+	c.Get()
+	if v, ok := c.PeekOldest(); ok {
+		t.Error(v, ok)
+	}
+}
+
+func TestAt(t *testing.T) {
+	c := NewCircularBuffer(5)
+
+	if v, ok := c.At(0); ok {
+		t.Error(v, ok)
+	}
+
+	// Wrap around the end of the backing array.
+	for i := 0; i < 7; i++ {
+		c.NBPush(i)
+	}
+	for i := 0; i < 4; i++ {
+		if v, ok := c.At(i); !ok || v != i+3 {
+			t.Error(i, v, ok)
+		}
+		if v, ok := c.At(-1 - i); !ok || v != 6-i {
+			t.Error(i, v, ok)
+		}
+	}
+	for _, i := range []int{4, 5, -5, -6} {
+		if v, ok := c.At(i); ok {
+			t.Error(i, v, ok)
+		}
+	}
+	if c.Length() != 4 {
+		t.Error(c.Length())
+	}
+}
+
+func TestPushAll(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	if ev := c.PushAll([]int{0, 1}); len(ev) != 0 {
+		t.Error(ev)
+	}
+	ev := c.PushAll([]int{2, 3, 4, 5})
+	if len(ev) != 3 || ev[0] != 0 || ev[1] != 1 || ev[2] != 2 {
+		t.Error(ev)
+	}
+	for i := 3; i < 6; i++ {
+		if v := c.Get(); v != i {
+			t.Error(v)
+		}
+	}
+
+	var evicted []int
+	c.Evict = func(v int) {
+		evicted = append(evicted, v)
+	}
+	if ev := c.PushAll([]int{6, 7, 8, 9}); ev != nil {
+		t.Error(ev)
+	}
+	if len(evicted) != 1 || evicted[0] != 6 {
+		t.Error(evicted)
+	}
+
+	if s := c.Stats(); s.Pushed != 10 || s.Evicted != 4 {
+		t.Error(s)
+	}
+}
+
+func TestGetN(t *testing.T) {
+	c := NewBuffer[int](100)
+
+	// Not enough items, returns what's there after the wait.
+	c.NBPush(0)
+	start := time.Now()
+	items := c.GetN(2, 10, 10*time.Millisecond)
+	if len(items) != 1 || items[0] != 0 || time.Since(start) < 10*time.Millisecond {
+		t.Error(items, time.Since(start))
+	}
+	if items := c.GetN(1, 10, time.Millisecond); len(items) != 0 {
+		t.Error(items)
+	}
+
+	// Capped at max.
+	for i := 0; i < 20; i++ {
+		c.NBPush(i)
+	}
+	items = c.GetN(5, 15, time.Second)
+	if len(items) != 15 || items[0] != 0 || items[14] != 14 {
+		t.Error(items)
+	}
+	items = c.GetN(0, 15, time.Second)
+	if len(items) != 5 || items[0] != 15 {
+		t.Error(items)
+	}
+
+	// Waits for min items.
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			c.NBPush(i)
+		}
+	}()
+	items = c.GetN(3, 10, time.Second)
+	if len(items) != 3 || items[0] != 0 || items[2] != 2 {
+		t.Error(items)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestDrainAll(t *testing.T) {
+	c := NewBuffer[int](5)
+
+	if items := c.DrainAll(); len(items) != 0 {
+		t.Error(items)
+	}
+
+	for i := 0; i < 6; i++ {
+		c.NBPush(i)
+	}
+	items := c.DrainAll()
+	if len(items) != 4 || items[0] != 2 || items[3] != 5 {
+		t.Error(items)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+
+	// A consumer blocks until the next push.
+	done := make(chan int)
+	go func() {
+		done <- c.Get()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	c.NBPush(6)
+	if v := <-done; v != 6 {
+		t.Error(v)
+	}
+}
+
+func TestReset(t *testing.T) {
+	c := NewCircularBuffer(4, WithClosedPushMode(ClosedPushError))
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	c.Close()
+	c.Reset(false)
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+	if s := c.Stats(); s != (Stats{Cap: 3}) {
+		t.Error(s)
+	}
+
+	// Reopened and usable again.
+	for i := 0; i < 3; i++ {
+		if v := c.NBPush(i); v != nil {
+			t.Error(v)
+		}
+	}
+	var evicted, hooked []interface{}
+	c.Evict = func(v interface{}) {
+		evicted = append(evicted, v)
+	}
+	c.SetHooks(Hooks[interface{}]{OnEvict: func(v interface{}) {
+		hooked = append(hooked, v)
+	}})
+	spill := c.EvictedChan()
+	c.Reset(true)
+	if len(evicted) != 3 || evicted[0] != 0 || evicted[2] != 2 {
+		t.Error(evicted)
+	}
+	if len(hooked) != 3 || hooked[0] != 0 || len(spill) != 3 {
+		t.Error(hooked, len(spill))
+	}
+
+	// Nothing left to take.
+	if v, ok := c.TryGet(); ok {
+		t.Error(v)
+	}
+	c.NBPush(3)
+	if v := c.Get(); v != 3 {
+		t.Error(v)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestResize(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	// Wrapped around the end of the backing array.
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+
+	if ev := c.Resize(10); len(ev) != 0 {
+		t.Error(ev)
+	}
+	if c.Cap() != 9 || c.Length() != 3 {
+		t.Error(c.Cap(), c.Length())
+	}
+	for i := 5; i < 11; i++ {
+		if v := c.NBPush(i); v != 0 {
+			t.Error(v)
+		}
+	}
+	if r := c.NBPushResult(11); !r.Evicted || r.Value != 2 {
+		t.Error(r)
+	}
+
+	var evicted []int
+	c.Evict = func(v int) {
+		evicted = append(evicted, v)
+	}
+	c.Resize(3)
+	if len(evicted) != 7 || evicted[0] != 3 || evicted[6] != 9 {
+		t.Error(evicted)
+	}
+	if c.Cap() != 2 || c.Length() != 2 {
+		t.Error(c.Cap(), c.Length())
+	}
+	for i := 10; i < 12; i++ {
+		if v := c.Get(); v != i {
+			t.Error(v)
+		}
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestResizeBlockedConsumer(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	done := make(chan int)
+	go func() {
+		done <- c.Get()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	c.Resize(8)
+	c.NBPush(1)
+	if v := <-done; v != 1 {
+		t.Error(v)
+	}
+}
+
+func TestFull(t *testing.T) {
+	c := NewCircularBuffer(3)
+
+	if c.Capacity() != 2 || c.Full() {
+		t.Error(c.Capacity(), c.Full())
+	}
+	c.NBPush(0)
+	if c.Full() {
+		t.Error("full")
+	}
+	c.NBPush(1)
+	if !c.Full() || c.Free() != 0 {
+		t.Error(c.Full(), c.Free())
+	}
+	c.NBPush(2)
+	if !c.Full() {
+		t.Error("not full")
+	}
+	c.Get()
+	if c.Full() || c.Free() != 1 {
+		t.Error(c.Full(), c.Free())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	if items := c.Snapshot(); len(items) != 0 {
+		t.Error(items)
+	}
+	c.NBPush(0)
+	c.NBPush(1)
+	if items := c.Snapshot(); len(items) != 2 || items[0] != 0 || items[1] != 1 {
+		t.Error(items)
+	}
+
+	// Wrapped around the end of the backing array.
+	for i := 2; i < 6; i++ {
+		c.NBPush(i)
+	}
+	items := c.Snapshot()
+	if len(items) != 3 || items[0] != 3 || items[1] != 4 || items[2] != 5 {
+		t.Error(items)
+	}
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+}
+
+func TestAppendFrom(t *testing.T) {
+	c := NewBuffer[int](4)
+	d := NewBuffer[int](4)
+	c.NBPush(0)
+	c.NBPush(1)
+	d.NBPush(2)
+	d.NBPush(3)
+
+	if evicted := c.AppendFrom(d); len(evicted) != 1 || evicted[0] != 0 {
+		t.Error(evicted)
+	}
+	if d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+	if s := d.Stats(); s.Gotten != 2 {
+		t.Error(s)
+	}
+	for _, want := range []int{1, 2, 3} {
+		if v := c.Get(); v != want {
+			t.Error(v)
+		}
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := NewBuffer[int](4, WithOverflowPolicy(DropNewest))
+	c.NBPush(0)
+	c.NBPushMeta(1, "meta")
+	c.NBPush(2)
+	c.Close()
+
+	d := c.Clone()
+	if s := d.Stats(); s.Pushed != 0 || s.Length != 3 || s.Cap != 3 {
+		t.Error(s)
+	}
+	// Same policy, but open.
+	if v := d.NBPush(3); v != 3 {
+		t.Error(v)
+	}
+
+	// The copies are independent.
+	c.Get()
+	if v := d.Get(); v != 0 {
+		t.Error(v)
+	}
+	if v, meta, _ := d.GetMeta(); v != 1 || meta != "meta" {
+		t.Error(v, meta)
+	}
+	if v := d.Get(); v != 2 {
+		t.Error(v)
+	}
+	if c.Length() != 2 {
+		t.Error(c.Length())
+	}
+	if d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	c := NewBuffer[int](4)
+	dst := make([]int, 2)
+
+	if n := c.CopyTo(dst); n != 0 {
+		t.Error(n)
+	}
+	// Wrapped around the end of the backing array.
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	if n := c.CopyTo(dst); n != 2 || dst[0] != 2 || dst[1] != 3 {
+		t.Error(n, dst)
+	}
+	dst = make([]int, 5)
+	if n := c.CopyTo(dst); n != 3 || dst[0] != 2 || dst[1] != 3 || dst[2] != 4 {
+		t.Error(n, dst)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { c.CopyTo(dst) }); allocs != 0 {
+		t.Error(allocs)
+	}
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+}
@@ -0,0 +1,74 @@
+package circularbuffer
+
+import (
+	"context"
+	"sync"
+)
+
+// Wait for at least one item and remove up to max oldest items. The
+// items count as claimed until the returned release function is
+// called, which the consumer should do once it has processed them.
+// WaitEmpty doesn't return while any items are claimed. Calling release
+// more than once has no effect.
+//
+// If ctx is done, or the buffer is closed and drained, before any item
+// arrives returns no items and a release function that does nothing.
+// Expired items are skipped and evicted, as in Get.
+func (b *Buffer[T]) ClaimBatch(ctx context.Context, max int) (items []T, release func()) {
+	var expired []T
+	b.lock.Lock()
+	for len(items) == 0 {
+		if err := b.waitItemsLocked(ctx, 1); err != nil {
+			evict, hooks := b.Evict, b.hooks
+			b.lock.Unlock()
+			reportExpired(expired, evict, &hooks)
+			return nil, func() {}
+		}
+		for len(items) == 0 || len(items) < max {
+			v, ok := b.takeLiveLocked(false, &expired)
+			if !ok {
+				break
+			}
+			items = append(items, v)
+		}
+	}
+	b.claimed += len(items)
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.taken(false, items...)
+
+	var once sync.Once
+	return items, func() {
+		once.Do(func() {
+			b.lock.Lock()
+			b.claimed -= len(items)
+			b.processed += uint64(len(items))
+			b.signalIdleLocked()
+			b.lock.Unlock()
+		})
+	}
+}
+
+// Block until the buffer is empty and all the claimed batches have
+// been released.
+func (b *Buffer[T]) WaitEmpty() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.idle == nil {
+		b.idle = sync.NewCond(&b.lock)
+	}
+	for b.start != b.pos || b.claimed > 0 {
+		b.idle.Wait()
+	}
+}
+
+// Wake up WaitEmpty callers if the buffer became idle. Must be called
+// with the lock held.
+func (b *Buffer[T]) signalIdleLocked() {
+	if b.idle != nil && b.start == b.pos && b.claimed == 0 {
+		b.idle.Broadcast()
+	}
+}
@@ -0,0 +1,74 @@
+package circularbuffer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClaimBatch(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+
+	items, release1 := c.ClaimBatch(context.Background(), 3)
+	if len(items) != 3 || items[0] != 0 || items[2] != 2 {
+		t.Error(items)
+	}
+	items, release2 := c.ClaimBatch(context.Background(), 3)
+	if len(items) != 2 || items[0] != 3 || items[1] != 4 {
+		t.Error(items)
+	}
+
+	// Drained, but not processed yet.
+	var done int32
+	go func() {
+		c.WaitEmpty()
+		atomic.StoreInt32(&done, 1)
+	}()
+
+	release1()
+	release1()
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&done) != 0 {
+		t.Error("WaitEmpty returned with a claimed batch")
+	}
+
+	release2()
+	for i := 0; i < 100 && atomic.LoadInt32(&done) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&done) != 1 {
+		t.Error("WaitEmpty didn't return")
+	}
+
+	if s := c.Stats(); s.Gotten != 5 || s.Processed != 5 {
+		t.Error(s)
+	}
+}
+
+func TestClaimBatchBlocking(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	items, release := c.ClaimBatch(ctx, 10)
+	if len(items) != 0 {
+		t.Error(items)
+	}
+	release()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.NBPush(1)
+	}()
+	items, release = c.ClaimBatch(context.Background(), 10)
+	if len(items) != 1 || items[0] != 1 {
+		t.Error(items)
+	}
+	release()
+	c.WaitEmpty()
+}
@@ -0,0 +1,52 @@
+package circularbuffer
+
+// What to do when pushing to a closed buffer.
+type ClosedPushMode int
+
+const (
+	// Panic, like sending to a closed channel. This is the default.
+	ClosedPushPanic ClosedPushMode = iota
+	// Drop the item and treat it as evicted.
+	ClosedPushDrop
+	// Refuse the item: NBPushErr returns ErrClosed, NBPush (which
+	// can't report errors) returns the item back. Evict is not
+	// called.
+	ClosedPushError
+)
+
+// Set what happens when pushing to a closed buffer.
+func WithClosedPushMode(mode ClosedPushMode) Option {
+	return func(c *config) {
+		c.closedMode = mode
+	}
+}
+
+// Close the buffer for pushes. Items already in the buffer can still
+// be consumed, after that blocked consumers wake up and Get/Pop return
+// the zero value, GetContext and friends ErrClosed. Closing a closed
+// buffer has no effect.
+func (b *Buffer[T]) Close() {
+	b.lock.Lock()
+	b.closed = true
+	if b.space != nil {
+		// Blocked pushers must not wait for space anymore.
+		b.space.Broadcast()
+	}
+	if b.items != nil {
+		// Blocked consumers take what's left or give up.
+		b.items.Broadcast()
+	}
+	b.lock.Unlock()
+}
+
+// Nonblocking push, like NBPush, but returns ErrClosed when pushing
+// to a buffer closed with the ClosedPushError mode, and ErrFull when
+// pushing to a full buffer with the Error policy.
+func (b *Buffer[T]) NBPushErr(v T) (T, error) {
+	evictv, _, _, err := b.push(v, nil, 0)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return evictv, nil
+}
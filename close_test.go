@@ -0,0 +1,145 @@
+package circularbuffer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func closedBuffer(mode ClosedPushMode) *CircularBuffer {
+	c := NewCircularBuffer(4, WithClosedPushMode(mode))
+	c.NBPush(1)
+	c.NBPush(2)
+	c.Close()
+	return c
+}
+
+func checkDrain(t *testing.T, c *CircularBuffer) {
+	for i := 1; i <= 2; i++ {
+		if v := c.Get(); v != i {
+			t.Error(v)
+		}
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestClosedPushPanic(t *testing.T) {
+	c := closedBuffer(ClosedPushPanic)
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic")
+			}
+		}()
+		c.NBPush(3)
+	}()
+
+	checkDrain(t, c)
+}
+
+func TestClosedPushDrop(t *testing.T) {
+	c := closedBuffer(ClosedPushDrop)
+
+	if v := c.NBPush(3); v != 3 {
+		t.Error(v)
+	}
+	if v, err := c.NBPushErr(4); v != 4 || err != nil {
+		t.Error(v, err)
+	}
+
+	var evicted []interface{}
+	c.Evict = func(v interface{}) {
+		evicted = append(evicted, v)
+	}
+	if v := c.NBPush(5); v != nil || len(evicted) != 1 || evicted[0] != 5 {
+		t.Error(v, evicted)
+	}
+	if s := c.Stats(); s.Evicted != 3 {
+		t.Error(s)
+	}
+
+	checkDrain(t, c)
+}
+
+func TestClosedPushError(t *testing.T) {
+	c := closedBuffer(ClosedPushError)
+
+	c.Evict = func(v interface{}) {
+		t.Error(v)
+	}
+	if v, err := c.NBPushErr(3); v != nil || err != ErrClosed {
+		t.Error(v, err)
+	}
+	if v := c.NBPush(4); v != 4 {
+		t.Error(v)
+	}
+	if v := c.PushAll([]interface{}{5, 6}); !reflect.DeepEqual(v, []interface{}{5, 6}) {
+		t.Error(v)
+	}
+	if s := c.Stats(); s.Evicted != 0 || s.Pushed != 2 {
+		t.Error(s)
+	}
+
+	checkDrain(t, c)
+}
+
+func TestCloseWakesConsumers(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	done := make(chan error, 3)
+	go func() {
+		_, err := c.GetContext(context.Background())
+		done <- err
+	}()
+	go func() {
+		_, err := c.PopTimeout(time.Hour)
+		done <- err
+	}()
+	go func() {
+		items, _ := c.ClaimBatch(context.Background(), 2)
+		if len(items) != 0 {
+			t.Error(items)
+		}
+		done <- ErrClosed
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != ErrClosed {
+			t.Error(err)
+		}
+	}
+
+	if v := c.Get(); v != 0 {
+		t.Error(v)
+	}
+	if items := c.GetN(1, 2, time.Hour); len(items) != 0 {
+		t.Error(items)
+	}
+
+	// Reopened buffer blocks again.
+	c.Reset(false)
+	if _, err := c.GetTimeout(time.Millisecond); err != ErrTimeout {
+		t.Error(err)
+	}
+	c.NBPush(1)
+	c.NBPush(2)
+	c.Close()
+	if v, err := c.GetContext(context.Background()); v != 1 || err != nil {
+		t.Error(v, err)
+	}
+	if v, err := c.PopContext(context.Background()); v != 2 || err != nil {
+		t.Error(v, err)
+	}
+	if _, err := c.GetContext(context.Background()); err != ErrClosed {
+		t.Error(err)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,37 @@
+package circularbuffer
+
+// Initial number of cells of a cost bounded buffer. It grows as needed.
+const costBoundedInitialSize = 16
+
+// Create CircularBuffer object bounded by the total cost of its items
+// rather than by their number. Same as New with WithMaxCost.
+func NewCostBoundedBuffer(maxCost int64, costOf func(interface{}) int64) *CircularBuffer {
+	return New(costBoundedInitialSize, WithMaxCost(maxCost, costOf))
+}
+
+// Bound the buffer by the total cost of its items (e.g. their size in
+// bytes) rather than by their number. After every push the oldest
+// items are evicted until the total cost is at most maxCost, possibly
+// including the pushed item itself. The size passed to the constructor
+// is only the initial number of cells, the backing array grows as
+// needed and the overflow policy is ignored.
+//
+// costOf must return the same cost for an item every time. It is
+// called with the lock held and must not use the buffer. Its item type
+// must match the buffer's, use interface{} for CircularBuffer. If a
+// push evicts several items NBPush returns only the oldest one, set
+// the Evict callback to see all of them.
+func WithMaxCost[T any](maxCost int64, costOf func(v T) int64) Option {
+	return func(c *config) {
+		c.maxCost = maxCost
+		c.costFn = costOf
+	}
+}
+
+// Total cost of the items in a cost bounded buffer.
+func (b *Buffer[T]) Cost() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.totalCost
+}
@@ -0,0 +1,109 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestCostBounded(t *testing.T) {
+	c := NewCostBoundedBuffer(100, func(v interface{}) int64 {
+		return int64(v.(int))
+	})
+
+	var evicted []int
+	c.Evict = func(v interface{}) {
+		evicted = append(evicted, v.(int))
+	}
+
+	// Many cheap items, more than the initial number of cells.
+	for i := 0; i < 50; i++ {
+		c.NBPush(1)
+	}
+	if c.Length() != 50 || c.Cost() != 50 || len(evicted) != 0 {
+		t.Error(c.Length(), c.Cost(), evicted)
+	}
+
+	// Evicts 30 oldest items.
+	c.NBPush(80)
+	if c.Length() != 21 || c.Cost() != 100 || len(evicted) != 30 {
+		t.Error(c.Length(), c.Cost(), evicted)
+	}
+
+	c.Pop()
+	c.NBPush(90) // evicts 10 more
+	if c.Length() != 11 || c.Cost() != 100 || len(evicted) != 40 {
+		t.Error(c.Length(), c.Cost(), evicted)
+	}
+
+	// Too expensive to be kept at all, evicts everything.
+	if r := c.NBPushResult(101); r.OK || !r.Evicted {
+		t.Error(r)
+	}
+	if c.Length() != 0 || c.Cost() != 0 {
+		t.Error(c.Length(), c.Cost())
+	}
+
+	want := make([]int, 50, 52)
+	for i := range want {
+		want[i] = 1
+	}
+	want = append(want, 90, 101)
+	if len(evicted) != len(want) {
+		t.Fatal(evicted)
+	}
+	for i := range want {
+		if evicted[i] != want[i] {
+			t.Error(i, evicted[i])
+		}
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestCostBoundedBlockedGet(t *testing.T) {
+	c := NewCostBoundedBuffer(10, func(v interface{}) int64 {
+		return int64(v.(int))
+	})
+
+	done := make(chan interface{})
+	go func() {
+		done <- c.Get()
+	}()
+
+	// The consumer may claim the item before it gets evicted, it
+	// must then wait for the next one.
+	c.NBPush(5)
+	c.NBPush(10)
+	for i := 0; i < costBoundedInitialSize*2; i++ {
+		c.NBPush(0)
+	}
+	v := <-done
+	if v != 5 && v != 10 && v != 0 {
+		t.Error(v)
+	}
+}
+
+func TestWithMaxCost(t *testing.T) {
+	c := NewBuffer[[]byte](4, WithMaxCost(100, func(v []byte) int64 {
+		return int64(len(v))
+	}))
+	c.NBPush(make([]byte, 10))
+	c.NBPush(make([]byte, 60))
+	for i := 0; i < 10; i++ {
+		c.NBPush(make([]byte, 1))
+	}
+	// Grew past the initial cells.
+	if c.Length() != 12 || c.Cost() != 80 {
+		t.Error(c.Length(), c.Cost())
+	}
+	// Evicts just the oldest.
+	if v := c.NBPush(make([]byte, 30)); len(v) != 10 || c.Length() != 12 || c.Cost() != 100 {
+		t.Error(len(v), c.Length(), c.Cost())
+	}
+	c.DrainAll()
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
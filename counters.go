@@ -0,0 +1,66 @@
+package circularbuffer
+
+// Cumulative counters with the same meaning for every buffer variant,
+// for monitoring code that handles several of them. See Stats for the
+// more detailed counters of Buffer.
+type Counters struct {
+	Length  int    // items in the buffer
+	Pushed  uint64 // items pushed
+	Taken   uint64 // items removed by consumers
+	Evicted uint64 // items evicted on overflow
+}
+
+// Counters of the buffer, taken consistently under the lock. Items
+// rejected on overflow count as pushed and evicted, as in Stats.
+func (b *Buffer[T]) Counters() Counters {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return Counters{
+		Length:  int(b.used()),
+		Pushed:  b.pushed,
+		Taken:   b.gotten + b.popped,
+		Evicted: b.evicted,
+	}
+}
+
+// Counters of the buffer. They are derived from the atomic indexes,
+// so reading them doesn't slow the producers and consumers down, but
+// they are only approximately consistent with each other under
+// concurrent use.
+func (b *LockFreeBuffer[T]) Counters() Counters {
+	evicted := b.evicted.Load()
+	tail := b.tail.Load()
+	head := b.head.Load()
+	return Counters{
+		Length:  int(head - tail),
+		Pushed:  head,
+		Taken:   tail - evicted,
+		Evicted: evicted,
+	}
+}
+
+// Counters of the ring, see LockFreeBuffer.Counters. Nothing is ever
+// evicted.
+func (b *SPSCBuffer[T]) Counters() Counters {
+	tail := b.tail.Load()
+	head := b.head.Load()
+	return Counters{
+		Length: int(head - tail),
+		Pushed: head,
+		Taken:  tail,
+	}
+}
+
+// Counters summed over all the shards.
+func (s *ShardedBuffer) Counters() Counters {
+	var total Counters
+	for _, shard := range s.shards {
+		c := shard.Counters()
+		total.Length += c.Length
+		total.Pushed += c.Pushed
+		total.Taken += c.Taken
+		total.Evicted += c.Evicted
+	}
+	return total
+}
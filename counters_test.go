@@ -0,0 +1,40 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	check := func(name string, c Counters, length int, pushed, taken, evicted uint64) {
+		t.Helper()
+		if c.Length != length || c.Pushed != pushed || c.Taken != taken || c.Evicted != evicted {
+			t.Error(name, c)
+		}
+	}
+
+	b := NewBuffer[int](4)
+	lf := NewLockFreeBuffer[int](4)
+	for i := 0; i < 6; i++ {
+		b.NBPush(i)
+		lf.NBPush(i)
+	}
+	b.Get()
+	b.Pop()
+	lf.Get()
+	check("Buffer", b.Counters(), 1, 6, 2, 3)
+	check("LockFreeBuffer", lf.Counters(), 3, 6, 1, 2)
+
+	sp := NewSPSCBuffer[int](4)
+	for i := 0; i < 6; i++ {
+		sp.TryPush(i)
+	}
+	sp.TryGet()
+	check("SPSCBuffer", sp.Counters(), 3, 4, 1, 0)
+
+	s := NewShardedBuffer(2, 4, nil)
+	for i := 0; i < 8; i++ {
+		s.NBPush(i)
+	}
+	s.Get()
+	check("ShardedBuffer", s.Counters(), 5, 8, 1, 2)
+}
@@ -0,0 +1,12 @@
+//go:build circbufdebug
+
+package circularbuffer
+
+// Verify internal consistency of the buffer, see Verify, panicking on
+// violation. Compiled in only with the circbufdebug build tag, must be
+// called with the lock held.
+func (b *Buffer[T]) checkInvariants() {
+	if err := b.invariantsLocked(); err != nil {
+		panic(err.Error())
+	}
+}
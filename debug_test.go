@@ -0,0 +1,43 @@
+//go:build circbufdebug
+
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestDebugInvariants(t *testing.T) {
+	c := NewCircularBuffer(4)
+
+	// Every operation below runs checkInvariants, which panics on
+	// violation.
+	for i := 0; i < 10; i++ {
+		c.NBPush(i)
+	}
+	c.NBPush(nil)
+	c.Get()
+	c.Pop()
+	c.Get()
+	c.NBPush(1)
+	c.Pop()
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestDebugInvariantsDetectStaleCell(t *testing.T) {
+	c := NewCircularBuffer(4)
+	c.NBPush(1)
+	c.NBPush(2)
+
+	// Leave a stale reference in an unused cell.
+	c.buffer[3] = 3
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+	c.Get()
+}
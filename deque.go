@@ -0,0 +1,129 @@
+package circularbuffer
+
+import (
+	"sync/atomic"
+)
+
+// Work-stealing deque (Chase-Lev) of fixed size, the core of a task
+// scheduler. The owner goroutine pushes and pops at the bottom, LIFO,
+// other workers steal from the top, FIFO. Only Steal is safe to call
+// from other goroutines, NBPush and Pop are owner-only.
+//
+// Pushing to a full deque evicts the oldest item, as in Buffer. Each
+// push allocates, as items are published to thieves through atomic
+// pointers.
+type WorkStealingDeque[T any] struct {
+	cells []atomic.Pointer[T]
+	mask  int64
+
+	// Called with the evicted item, instead of returning it from
+	// NBPush. Must be set before the deque is shared.
+	Evict func(v T)
+
+	_      cacheLinePad
+	top    atomic.Int64 // next item to steal
+	_      cacheLinePad
+	bottom atomic.Int64 // next free cell, owned by the owner
+	_      cacheLinePad
+}
+
+// Create WorkStealingDeque holding up to size items. The size is
+// rounded up to a power of two.
+func NewWorkStealingDeque[T any](size uint) *WorkStealingDeque[T] {
+	n := int64(ringSize(size))
+	return &WorkStealingDeque[T]{
+		cells: make([]atomic.Pointer[T], n),
+		mask:  n - 1,
+	}
+}
+
+// Push v at the bottom. Owner only. If the Evict callback is not set
+// returns the evicted item (if any), otherwise nil (zero value).
+func (d *WorkStealingDeque[T]) NBPush(v T) T {
+	var evictv T
+	b := d.bottom.Load()
+	for b-d.top.Load() > d.mask {
+		// Full, steal the oldest item from ourselves. If a thief
+		// wins the race there is room anyway.
+		if oldest, ok := d.steal(); ok {
+			if d.Evict != nil {
+				d.Evict(oldest)
+			} else {
+				evictv = oldest
+			}
+		}
+	}
+	p := new(T)
+	*p = v
+	d.cells[b&d.mask].Store(p)
+	// Publish the item to thieves.
+	d.bottom.Store(b + 1)
+	return evictv
+}
+
+// Pop the newest item from the bottom. Owner only. ok is false if the
+// deque is empty.
+func (d *WorkStealingDeque[T]) Pop() (v T, ok bool) {
+	b := d.bottom.Load() - 1
+	// Claim the cell before looking at top, so that thieves can't
+	// take it behind our back.
+	d.bottom.Store(b)
+	t := d.top.Load()
+	if t > b {
+		d.bottom.Store(b + 1)
+		return v, false
+	}
+	c := &d.cells[b&d.mask]
+	p := c.Load()
+	if t == b {
+		// The last item, race the thieves for it.
+		won := d.top.CompareAndSwap(t, t+1)
+		d.bottom.Store(b + 1)
+		if !won {
+			return v, false
+		}
+	}
+	c.CompareAndSwap(p, nil)
+	return *p, true
+}
+
+// Steal the oldest item from the top. Safe to call from any goroutine.
+// ok is false if the deque is empty.
+func (d *WorkStealingDeque[T]) Steal() (v T, ok bool) {
+	return d.steal()
+}
+
+func (d *WorkStealingDeque[T]) steal() (v T, ok bool) {
+	for {
+		t := d.top.Load()
+		b := d.bottom.Load()
+		if t >= b {
+			return v, false
+		}
+		c := &d.cells[t&d.mask]
+		p := c.Load()
+		if d.top.CompareAndSwap(t, t+1) {
+			// Drop the reference, unless the owner already
+			// reused the cell.
+			c.CompareAndSwap(p, nil)
+			return *p, true
+		}
+		// Another thief, or the owner, was faster. Retry.
+	}
+}
+
+// Number of items in the deque, approximate under concurrent use.
+func (d *WorkStealingDeque[T]) Length() int {
+	t := d.top.Load()
+	b := d.bottom.Load()
+	if b < t {
+		// The owner is in the middle of a Pop.
+		return 0
+	}
+	return int(b - t)
+}
+
+// Maximum number of items the deque can hold.
+func (d *WorkStealingDeque[T]) Cap() int {
+	return len(d.cells)
+}
@@ -0,0 +1,123 @@
+package circularbuffer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkStealingDeque(t *testing.T) {
+	d := NewWorkStealingDeque[int](3) // rounded up to 4
+	if d.Cap() != 4 {
+		t.Error(d.Cap())
+	}
+	if _, ok := d.Pop(); ok {
+		t.Error("not empty")
+	}
+	if _, ok := d.Steal(); ok {
+		t.Error("not empty")
+	}
+
+	for i := 1; i <= 4; i++ {
+		if v := d.NBPush(i); v != 0 {
+			t.Error(v)
+		}
+	}
+	// Full, the oldest item goes.
+	if v := d.NBPush(5); v != 1 {
+		t.Error(v)
+	}
+	var evicted []int
+	d.Evict = func(v int) {
+		evicted = append(evicted, v)
+	}
+	if v := d.NBPush(6); v != 0 || len(evicted) != 1 || evicted[0] != 2 {
+		t.Error(v, evicted)
+	}
+	if d.Length() != 4 {
+		t.Error(d.Length())
+	}
+
+	// Owner takes the newest, thieves the oldest.
+	if v, ok := d.Pop(); !ok || v != 6 {
+		t.Error(v, ok)
+	}
+	if v, ok := d.Steal(); !ok || v != 3 {
+		t.Error(v, ok)
+	}
+	if v, ok := d.Pop(); !ok || v != 5 {
+		t.Error(v, ok)
+	}
+	if v, ok := d.Pop(); !ok || v != 4 {
+		t.Error(v, ok)
+	}
+	if _, ok := d.Pop(); ok || d.Length() != 0 {
+		t.Error(ok, d.Length())
+	}
+	for i := range d.cells {
+		if d.cells[i].Load() != nil {
+			t.Error("cell not cleared", i)
+		}
+	}
+}
+
+func TestWorkStealingDequeConcurrent(t *testing.T) {
+	const thieves, n = 4, 20000
+	d := NewWorkStealingDeque[int](64)
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	mark := func(v int) {
+		mu.Lock()
+		if seen[v] {
+			t.Error("duplicate", v)
+		}
+		seen[v] = true
+		mu.Unlock()
+	}
+	d.Evict = mark
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				v, ok := d.Steal()
+				if ok {
+					mark(v)
+				} else if done.Load() {
+					return
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	// The owner pushes and every few items pops one back, racing
+	// the thieves for the last item.
+	for i := 0; i < n; i++ {
+		d.NBPush(i)
+		if i%3 == 0 {
+			if v, ok := d.Pop(); ok {
+				mark(v)
+			}
+		}
+	}
+	for {
+		v, ok := d.Pop()
+		if !ok {
+			break
+		}
+		mark(v)
+	}
+	done.Store(true)
+	wg.Wait()
+
+	// Every item was either taken or evicted, exactly once.
+	if len(seen) != n || d.Length() != 0 {
+		t.Error(len(seen), d.Length())
+	}
+}
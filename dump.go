@@ -0,0 +1,51 @@
+package circularbuffer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Short description for debugging, e.g. "Buffer[3/7 start=5 pos=0]":
+// occupancy, capacity and the indexes of the oldest item and the first
+// free cell.
+func (b *Buffer[T]) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.stringLocked()
+}
+
+func (b *Buffer[T]) stringLocked() string {
+	closed := ""
+	if b.closed {
+		closed = " closed"
+	}
+	return fmt.Sprintf("Buffer[%d/%d start=%d pos=%d%s]",
+		b.used(), b.capLocked(), b.start, b.pos, closed)
+}
+
+// Write String, the stats and the items, oldest first, one per line
+// with its cell index, formatted by format (fmt's %v if nil). The
+// state is taken under the lock, format is called after releasing it.
+func (b *Buffer[T]) Dump(w io.Writer, format func(v T) string) error {
+	b.lock.Lock()
+	header := b.stringLocked()
+	stats := b.statsLocked()
+	items := make([]T, b.used())
+	b.copyLocked(items)
+	start, mask := b.start, b.mask
+	b.lock.Unlock()
+
+	if format == nil {
+		format = func(v T) string { return fmt.Sprint(v) }
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, header)
+	fmt.Fprintf(bw, "pushed=%d evicted=%d gotten=%d popped=%d removed=%d peak=%d\n",
+		stats.Pushed, stats.Evicted, stats.Gotten, stats.Popped, stats.Removed, stats.PeakLength)
+	for i, v := range items {
+		fmt.Fprintf(bw, "%4d [%d] %s\n", i, (start+uint(i))&mask, format(v))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,37 @@
+package circularbuffer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	c := NewBuffer[string](4)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		c.NBPush(s)
+	}
+	if s := c.String(); s != "Buffer[3/3 start=1 pos=0]" {
+		t.Error(s)
+	}
+
+	var sb strings.Builder
+	if err := c.Dump(&sb, strings.ToUpper); err != nil {
+		t.Error(err)
+	}
+	want := "Buffer[3/3 start=1 pos=0]\n" +
+		"pushed=4 evicted=1 gotten=0 popped=0 removed=0 peak=3\n" +
+		"   0 [1] B\n" +
+		"   1 [2] C\n" +
+		"   2 [3] D\n"
+	if sb.String() != want {
+		t.Error(sb.String())
+	}
+
+	c.Reset(false)
+	c.Close()
+	sb.Reset()
+	c.Dump(&sb, nil)
+	if !strings.HasPrefix(sb.String(), "Buffer[0/3 start=0 pos=0 closed]\n") {
+		t.Error(sb.String())
+	}
+}
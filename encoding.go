@@ -0,0 +1,108 @@
+package circularbuffer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Largest size accepted from encoded data, so that a corrupt or hostile
+// encoding can't make the decoder allocate a huge buffer.
+const maxEncodedSize = 1 << 24
+
+// Serialized form of a Buffer.
+type bufferState[T any] struct {
+	Size  uint `json:"size"`  // as passed to NewBuffer
+	Items []T  `json:"items"` // oldest first
+}
+
+// Encode the size and the items, oldest first, as
+// {"size": 8, "items": [...]}. Metadata, deadlines, keys, counters and
+// configuration are not included.
+func (b *Buffer[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.state())
+}
+
+// Replace the buffer's size and items with the encoded ones, see
+// MarshalJSON. The items already in the buffer are discarded without
+// being evicted (only OnRecycle sees them), configuration and counters
+// are kept. Works on a zero Buffer too, e.g. a struct field, which then
+// has the default configuration. Sizes above 1<<24 are rejected.
+func (b *Buffer[T]) UnmarshalJSON(data []byte) error {
+	var s bufferState[T]
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return b.restore(&s)
+}
+
+// Like MarshalJSON, in gob. Items stored as interfaces, as in
+// CircularBuffer, need their concrete types registered with
+// gob.Register.
+func (b *Buffer[T]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(b.state()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Like UnmarshalJSON, in gob.
+func (b *Buffer[T]) GobDecode(data []byte) error {
+	var s bufferState[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	return b.restore(&s)
+}
+
+func (b *Buffer[T]) state() *bufferState[T] {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s := &bufferState[T]{Size: b.size, Items: make([]T, b.used())}
+	b.copyLocked(s.Items)
+	return s
+}
+
+func (b *Buffer[T]) restore(s *bufferState[T]) error {
+	if s.Size == 0 || s.Size > maxEncodedSize {
+		return errors.New("circularbuffer: encoded size out of range")
+	}
+	if uint(len(s.Items)) > s.Size-1 {
+		return errors.New("circularbuffer: encoded items don't fit in the encoded size")
+	}
+	var dropped []T
+	b.lock.Lock()
+	if b.buffer == nil {
+		// Zero Buffer, set up what NewBuffer would.
+		b.now = time.Now
+		b.resetRateLocked()
+		b.initSize = s.Size
+	}
+	hooks := b.hooks
+	for b.start != b.pos {
+		if hooks.OnRecycle != nil {
+			dropped = append(dropped, b.buffer[b.start])
+		}
+		b.clearCell(b.start)
+		b.start = (b.start + 1) & b.mask
+	}
+	b.resizeLocked(s.Size)
+	for _, v := range s.Items {
+		b.setCell(b.pos, v)
+		b.pos = (b.pos + 1) & b.mask
+	}
+	if b.items != nil {
+		b.items.Broadcast()
+	}
+	b.signalIdleLocked()
+	b.lengthChangedLocked()
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	hooks.recycle(dropped...)
+	return nil
+}
@@ -0,0 +1,78 @@
+package circularbuffer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	c := NewBuffer[int](4)
+	for i := 1; i <= 4; i++ {
+		c.NBPush(i)
+	}
+	data, err := json.Marshal(c)
+	if err != nil || string(data) != `{"size":4,"items":[2,3,4]}` {
+		t.Error(string(data), err)
+	}
+
+	// Into an existing buffer, replacing its items and size.
+	d := NewBuffer[int](2)
+	d.NBPush(7)
+	if err := json.Unmarshal(data, d); err != nil {
+		t.Error(err)
+	}
+	if s := d.Snapshot(); len(s) != 3 || s[0] != 2 || s[2] != 4 || d.Cap() != 3 {
+		t.Error(s, d.Cap())
+	}
+	d.NBPush(5)
+	if v := d.Get(); v != 3 {
+		t.Error(v)
+	}
+
+	// Into a zero Buffer.
+	var s struct{ B Buffer[int] }
+	if err := json.Unmarshal([]byte(`{"B":{"size":3,"items":[1]}}`), &s); err != nil {
+		t.Error(err)
+	}
+	if v := s.B.Get(); v != 1 {
+		t.Error(v)
+	}
+	if s.B.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+
+	if err := json.Unmarshal([]byte(`{"size":2,"items":[1,2]}`), d); err == nil {
+		t.Error(err)
+	}
+	// Hostile sizes are rejected, not allocated.
+	for _, size := range []string{"0", "16777217", "9223372036854775809"} {
+		if err := json.Unmarshal([]byte(`{"size":`+size+`,"items":[]}`), d); err == nil {
+			t.Error(size)
+		}
+	}
+
+	d.Get()
+	d.Get()
+	if d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestGob(t *testing.T) {
+	c := NewBuffer[string](4)
+	c.NBPush("a")
+	c.NBPush("b")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		t.Fatal(err)
+	}
+	d := NewBuffer[string](1)
+	if err := gob.NewDecoder(&buf).Decode(d); err != nil {
+		t.Fatal(err)
+	}
+	if s := d.Snapshot(); len(s) != 2 || s[0] != "a" || s[1] != "b" || d.Cap() != 3 {
+		t.Error(s)
+	}
+}
@@ -0,0 +1,33 @@
+package circularbuffer
+
+import (
+	"errors"
+)
+
+var (
+	// Returned by pushes to a closed buffer, see ClosedPushError, and
+	// by consumers once a closed buffer is drained.
+	ErrClosed = errors.New("circularbuffer: buffer is closed")
+
+	// Returned by TryPush, or NBPushErr with the Error policy, when the
+	// buffer is full.
+	ErrFull = errors.New("circularbuffer: buffer is full")
+
+	// Returned by GetErr and PopErr when the buffer is empty.
+	ErrEmpty = errors.New("circularbuffer: buffer is empty")
+
+	// Returned by GetTimeout and PopTimeout.
+	ErrTimeout = errors.New("circularbuffer: timed out waiting for an item")
+
+	// Signals pushLocked callers to panic once they release the lock.
+	errPushPanic = errors.New("circularbuffer: push to closed buffer")
+)
+
+// Report errors instead of panicking: pushes to a closed buffer return
+// ErrClosed whatever the ClosedPushMode. Use with NBPushErr, which
+// returns the error, or NBPush, which returns the item back.
+func WithErrors() Option {
+	return func(c *config) {
+		c.noPanic = true
+	}
+}
@@ -0,0 +1,35 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	c := NewBuffer[int](3, WithErrors())
+
+	if _, err := c.GetErr(); err != ErrEmpty {
+		t.Error(err)
+	}
+	if _, err := c.PopErr(); err != ErrEmpty {
+		t.Error(err)
+	}
+
+	c.NBPush(1)
+	c.NBPush(2)
+	if v, err := c.GetErr(); v != 1 || err != nil {
+		t.Error(v, err)
+	}
+	if v, err := c.PopErr(); v != 2 || err != nil {
+		t.Error(v, err)
+	}
+
+	// Closed buffer with the default ClosedPushPanic mode.
+	c.Close()
+	if _, err := c.NBPushErr(3); err != ErrClosed {
+		t.Error(err)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
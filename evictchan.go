@@ -0,0 +1,38 @@
+package circularbuffer
+
+import (
+	"sync/atomic"
+)
+
+// Channel receiving evicted items, see EvictedChan.
+type evictChan[T any] struct {
+	ch      chan T
+	dropped atomic.Uint64
+}
+
+// Channel receiving a copy of every evicted item, including expired
+// and rejected ones, like the OnEvict hook. Lets a separate goroutine
+// deal with them, e.g. write them to a spill file, without slowing
+// down the producers. The channel holds up to Cap items, sends never
+// block: when the channel is full the item is dropped and counted in
+// Stats.EvictedChanDropped. The Evict callback and hooks still run.
+//
+// The channel is created on the first call, later calls return the
+// same one. It's never closed, it's not inherited by Clone.
+func (b *Buffer[T]) EvictedChan() <-chan T {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.hooks.spill == nil {
+		b.hooks.spill = &evictChan[T]{ch: make(chan T, b.capLocked())}
+	}
+	return b.hooks.spill.ch
+}
+
+func (s *evictChan[T]) send(v T) {
+	select {
+	case s.ch <- v:
+	default:
+		s.dropped.Add(1)
+	}
+}
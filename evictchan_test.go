@@ -0,0 +1,54 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestEvictedChan(t *testing.T) {
+	c := NewBuffer[int](3)
+	var evicted []int
+	c.Evict = func(v int) { evicted = append(evicted, v) }
+	ch := c.EvictedChan()
+	if c.EvictedChan() != ch || cap(ch) != 2 {
+		t.Error(cap(ch))
+	}
+	// The channel survives SetHooks, but not Clone.
+	c.SetHooks(Hooks[int]{})
+	if c.Clone().EvictedChan() == ch {
+		t.Error("clone shares the channel")
+	}
+
+	for i := 1; i <= 6; i++ {
+		c.NBPush(i)
+	}
+	// 1..4 evicted, only 2 fit in the channel.
+	if len(evicted) != 4 {
+		t.Error(evicted)
+	}
+	if v := <-ch; v != 1 {
+		t.Error(v)
+	}
+	if v := <-ch; v != 2 {
+		t.Error(v)
+	}
+	if len(ch) != 0 {
+		t.Error(len(ch))
+	}
+	if s := c.DrainStats(); s.EvictedChanDropped != 2 {
+		t.Error(s)
+	}
+	if s := c.Stats(); s.EvictedChanDropped != 0 {
+		t.Error(s)
+	}
+
+	c.NBPush(7)
+	if v := <-ch; v != 5 {
+		t.Error(v)
+	}
+
+	c.Get()
+	c.Get()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,81 @@
+package circularbuffer
+
+// Remove all the items matching pred, keeping the order of the rest.
+// Returns the number of removed items. Removed items are not evicted,
+// neither the Evict callback nor the hooks see them, except OnRecycle
+// once the lock is released. pred is called with the lock held and
+// must not use the buffer.
+func (b *Buffer[T]) RemoveIf(pred func(v T) bool) int {
+	var dropped []T
+	b.lock.Lock()
+	hooks := b.hooks
+	removed := func() int {
+		defer b.lock.Unlock()
+		return b.removeIfLocked(pred, hooks.OnRecycle != nil, &dropped)
+	}()
+
+	hooks.recycle(dropped...)
+	return removed
+}
+
+// Remove the items matching pred, appending them to dropped if keep is
+// set. Must be called with the lock held.
+func (b *Buffer[T]) removeIfLocked(pred func(v T) bool, keep bool, dropped *[]T) int {
+	// Move the kept items towards start, over the removed ones.
+	used := b.used()
+	w := b.start
+	removed := 0
+	for n, r := uint(0), b.start; n < used; n, r = n+1, (r+1)&b.mask {
+		if pred(b.buffer[r]) {
+			if keep {
+				*dropped = append(*dropped, b.buffer[r])
+			}
+			if b.recording {
+				// Index among the items left by the
+				// previous removals.
+				b.oplog = append(b.oplog, Op{Kind: OpRemove, Value: int((w - b.start) & b.mask)})
+			}
+			b.clearCell(r)
+			removed++
+			continue
+		}
+		if w != r {
+			b.moveCell(w, r)
+		}
+		w = (w + 1) & b.mask
+	}
+	if removed == 0 {
+		return 0
+	}
+	b.pos = w
+	b.removed += uint64(removed)
+	b.signalIdleLocked()
+	if b.space != nil {
+		b.space.Broadcast()
+	}
+	b.lengthChangedLocked()
+	b.checkInvariants()
+	return removed
+}
+
+// Find the oldest item matching pred, without removing it. ok is false
+// if there is none. pred is called with the lock held and must not use
+// the buffer.
+func (b *Buffer[T]) Find(pred func(v T) bool) (v T, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	used := b.used()
+	for n, i := uint(0), b.start; n < used; n, i = n+1, (i+1)&b.mask {
+		if pred(b.buffer[i]) {
+			return b.buffer[i], true
+		}
+	}
+	return v, false
+}
+
+// Is any item matching pred in the buffer? See Find.
+func (b *Buffer[T]) Contains(pred func(v T) bool) bool {
+	_, ok := b.Find(pred)
+	return ok
+}
@@ -0,0 +1,81 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestRemoveIf(t *testing.T) {
+	c := NewBuffer[int](6)
+	// Wrap around the end of the backing array.
+	for i := 0; i < 8; i++ {
+		c.NBPush(i)
+	}
+	c.NBPushMeta(8, "meta")
+
+	odd := func(v int) bool { return v%2 == 1 }
+	if n := c.RemoveIf(odd); n != 2 {
+		t.Error(n)
+	}
+	if items := c.Snapshot(); len(items) != 3 || items[0] != 4 || items[1] != 6 || items[2] != 8 {
+		t.Error(items)
+	}
+	if n := c.RemoveIf(odd); n != 0 {
+		t.Error(n)
+	}
+	if s := c.Stats(); s.Removed != 2 || s.Length != 3 || s.Evicted != 4 {
+		t.Error(s)
+	}
+
+	// The freed cells can be reused.
+	c.NBPush(9)
+	c.NBPush(10)
+	if s := c.Stats(); s.Evicted != 4 {
+		t.Error(s)
+	}
+	for _, want := range []int{4, 6} {
+		if v := c.Get(); v != want {
+			t.Error(v)
+		}
+	}
+	if v, meta, _ := c.GetMeta(); v != 8 || meta != "meta" {
+		t.Error(v, meta)
+	}
+
+	if n := c.RemoveIf(func(v int) bool { return true }); n != 2 {
+		t.Error(n)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestFind(t *testing.T) {
+	c := NewBuffer[string](4)
+	for _, v := range []string{"a1", "b1", "a2", "b2"} {
+		c.NBPush(v)
+	}
+
+	prefix := func(p string) func(v string) bool {
+		return func(v string) bool { return v[:1] == p }
+	}
+	if v, ok := c.Find(prefix("b")); !ok || v != "b1" {
+		t.Error(v, ok)
+	}
+	if v, ok := c.Find(prefix("c")); ok || v != "" {
+		t.Error(v, ok)
+	}
+	if !c.Contains(prefix("a")) || c.Contains(prefix("c")) {
+		t.Error("Contains")
+	}
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+
+	c.DrainAll()
+	if c.Contains(prefix("a")) {
+		t.Error("found in empty buffer")
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
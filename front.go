@@ -0,0 +1,76 @@
+package circularbuffer
+
+// Nonblocking push of v at the oldest end, so that it's the next item
+// to get, e.g. to retry it first. When the buffer is full the newest
+// item is evicted, whatever the overflow policy, and cost bounded
+// buffers evict from the newest end too. Pushing to a closed buffer
+// behaves as NBPush does. If the Evict callback is not set returns the
+// evicted item (if any), otherwise nil (zero value).
+func (b *Buffer[T]) PushFront(v T) T {
+	var evictbuf [1]T
+	evicted := evictbuf[:0]
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	stored := false
+	var err error
+	if b.closed {
+		evicted, _, err = b.closedPushLocked(v, evicted)
+	} else {
+		b.pushed++
+		if b.recording {
+			b.oplog = append(b.oplog, Op{Kind: OpPushFront, Value: v})
+		}
+		if b.fullLocked() && b.start == b.pos {
+			// No room at all, v goes right away.
+			b.noteEvictionLocked()
+			evicted = append(evicted, v)
+		} else {
+			if b.fullLocked() {
+				evicted = append(evicted, b.evictNewestLocked())
+			}
+			b.pushFrontLocked(v)
+			for b.costOf != nil && b.totalCost > b.maxCost && b.start != b.pos {
+				evicted = append(evicted, b.evictNewestLocked())
+			}
+			// v is the oldest, it's gone only if everything is.
+			stored = b.start != b.pos
+		}
+	}
+	if err == errPushPanic {
+		b.lock.Unlock()
+		panic("circularbuffer: push to closed buffer")
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	if err != nil {
+		return v
+	}
+	hooks.pushed(v, stored, evicted)
+	var zero T
+	if len(evicted) == 0 {
+		return zero
+	}
+	if evict != nil {
+		hooks.handOff(evict, evicted...)
+		return zero
+	}
+	return evicted[0]
+}
+
+// Same as NBPush, for symmetry with PushFront.
+func (b *Buffer[T]) PushBack(v T) T {
+	return b.NBPush(v)
+}
+
+// Same as TryGet: take the oldest item without blocking.
+func (b *Buffer[T]) PopFront() (v T, ok bool) {
+	return b.TryGet()
+}
+
+// Same as TryPop: take the newest item without blocking.
+func (b *Buffer[T]) PopBack() (v T, ok bool) {
+	return b.TryPop()
+}
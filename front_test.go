@@ -0,0 +1,81 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestPushFront(t *testing.T) {
+	c := NewBuffer[int](4, WithClosedPushMode(ClosedPushError))
+	c.PushBack(2)
+	c.PushBack(3)
+	if v := c.PushFront(1); v != 0 {
+		t.Error(v)
+	}
+	// Full, the newest goes.
+	if v := c.PushFront(0); v != 3 {
+		t.Error(v)
+	}
+	if s := c.Snapshot(); len(s) != 3 || s[0] != 0 || s[2] != 2 {
+		t.Error(s)
+	}
+	if v, ok := c.PopFront(); !ok || v != 0 {
+		t.Error(v, ok)
+	}
+	if v, ok := c.PopBack(); !ok || v != 2 {
+		t.Error(v, ok)
+	}
+	if s := c.Stats(); s.Pushed != 4 || s.Evicted != 1 {
+		t.Error(s)
+	}
+
+	c.SetRecording(true)
+	c.PushFront(5)
+	d := NewBuffer[int](4)
+	d.NBPush(1)
+	d.Replay(c.OpLog())
+	if s := d.Snapshot(); len(s) != 2 || s[0] != 5 {
+		t.Error(s)
+	}
+
+	c.Get()
+	c.Get()
+	c.Close()
+	if v := c.PushFront(9); v != 9 || c.Length() != 0 {
+		t.Error(v)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestPushFrontCost(t *testing.T) {
+	c := NewBuffer[int](4, WithMaxCost(10, func(v int) int64 { return int64(v) }))
+	c.NBPush(4)
+	c.NBPush(5)
+	var evicted []int
+	c.Evict = func(v int) { evicted = append(evicted, v) }
+	c.PushFront(3)
+	if s := c.Snapshot(); len(s) != 2 || s[0] != 3 || s[1] != 4 || len(evicted) != 1 {
+		t.Error(s, evicted)
+	}
+	c.PushFront(11)
+	if c.Length() != 0 || len(evicted) != 4 {
+		t.Error(evicted)
+	}
+}
+
+func TestPushFrontNoRoom(t *testing.T) {
+	c := NewBuffer[int](1)
+	if v := c.PushFront(1); v != 1 {
+		t.Error(v)
+	}
+	if err := c.Verify(); err != nil {
+		t.Error(err)
+	}
+	if s := c.Stats(); s.Pushed != 1 || s.Evicted != 1 || s.Length != 0 {
+		t.Error(s)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,21 @@
+package circularbuffer
+
+// Start with the size passed to the constructor and double it whenever
+// a push finds the buffer full, up to maxSize, only then evicting or
+// blocking according to the overflow policy. Saves memory for many
+// buffers that are usually nearly empty but must absorb the odd burst.
+// Cap, Free and Dump report the current size, Full is true only at
+// maxSize. The buffer doesn't shrink by itself.
+func WithGrowth(maxSize uint) Option {
+	return func(c *config) {
+		c.maxSize = maxSize
+	}
+}
+
+// Grow the buffer if it's full and allowed to grow, see WithGrowth.
+// Must be called with the lock held, before storing an item.
+func (b *Buffer[T]) growLocked() {
+	if b.size < b.maxSize && b.used() == b.size-1 {
+		b.resizeLocked(min(2*b.size, b.maxSize))
+	}
+}
@@ -0,0 +1,52 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestWithGrowth(t *testing.T) {
+	c := NewBuffer[int](2, WithGrowth(16))
+	if c.Cap() != 1 || len(c.buffer) != 2 {
+		t.Error(c.Cap())
+	}
+	for i := 0; i < 9; i++ {
+		if v := c.NBPush(i); v != 0 || c.Full() {
+			t.Error(i, v)
+		}
+	}
+	// 2 -> 4 -> 8 -> 16.
+	if c.Cap() != 15 || c.Length() != 9 {
+		t.Error(c.Cap(), c.Length())
+	}
+	for i := 9; i < 15; i++ {
+		c.NBPush(i)
+	}
+	if !c.Full() {
+		t.Error(c.Length())
+	}
+	if v := c.NBPush(15); v != 0 || c.Length() != 15 || c.Cap() != 15 {
+		t.Error(v)
+	}
+	if s := c.Stats(); s.Evicted != 1 {
+		t.Error(s)
+	}
+
+	d := NewFromSlice([]int{1, 2, 3, 4, 5}, 2, WithGrowth(5))
+	if s := d.Snapshot(); len(s) != 4 || s[0] != 2 {
+		t.Error(s)
+	}
+
+	e := NewBuffer[int](2, WithGrowth(8), WithOverflowPolicy(Error))
+	for i := 0; i < 7; i++ {
+		if _, err := e.NBPushErr(i); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := e.NBPushErr(7); err != ErrFull {
+		t.Error(err)
+	}
+	e.PushFront(-1)
+	if v, _ := e.PopFront(); v != -1 {
+		t.Error(v)
+	}
+}
@@ -0,0 +1,86 @@
+package circularbuffer
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Histogram of the last N observations. Besides the ring of
+// observations it keeps them sorted, and counted per bucket, as they
+// come and go, so quantiles and bucket counts are read without copying
+// or sorting the window. Each observation costs O(N) in the worst case
+// for the sorted insert. Safe for concurrent use.
+type WindowHistogram struct {
+	b   *Buffer[float64]
+	agg *histogramAgg
+}
+
+type histogramAgg struct {
+	sorted []float64 // the window, ascending
+	bounds []float64 // upper bounds of the buckets, ascending
+	counts []uint64  // per bucket, the last one is unbounded
+}
+
+// Create WindowHistogram over the last n observations. bounds are the
+// inclusive upper bounds of the buckets, in ascending order; values
+// above the last one fall into an extra overflow bucket.
+func NewWindowHistogram(n uint, bounds []float64) *WindowHistogram {
+	b := NewBuffer[float64](n + 1)
+	agg := &histogramAgg{
+		sorted: make([]float64, 0, n),
+		bounds: slices.Clone(bounds),
+		counts: make([]uint64, len(bounds)+1),
+	}
+	b.agg = agg
+	return &WindowHistogram{b: b, agg: agg}
+}
+
+// Record v, pushing the oldest observation out of a full window. NaN
+// is ignored.
+func (h *WindowHistogram) Observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	h.b.NBPush(v)
+}
+
+// The q-quantile (0 <= q <= 1) of the window by the nearest-rank
+// method, e.g. 0.99 for p99. NaN if the window is empty.
+func (h *WindowHistogram) Quantile(q float64) float64 {
+	h.b.lock.Lock()
+	defer h.b.lock.Unlock()
+
+	sorted := h.agg.sorted
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// Append the number of observations in each bucket to dst and return
+// it. There is one more count than bounds, for the overflow bucket.
+func (h *WindowHistogram) Buckets(dst []uint64) []uint64 {
+	h.b.lock.Lock()
+	defer h.b.lock.Unlock()
+
+	return append(dst, h.agg.counts...)
+}
+
+// Number of observations in the window.
+func (h *WindowHistogram) Count() int {
+	return h.b.Length()
+}
+
+func (a *histogramAgg) add(v float64) {
+	i := sort.SearchFloat64s(a.sorted, v)
+	a.sorted = slices.Insert(a.sorted, i, v)
+	a.counts[sort.SearchFloat64s(a.bounds, v)]++
+}
+
+func (a *histogramAgg) remove(v float64) {
+	i := sort.SearchFloat64s(a.sorted, v)
+	a.sorted = slices.Delete(a.sorted, i, i+1)
+	a.counts[sort.SearchFloat64s(a.bounds, v)]--
+}
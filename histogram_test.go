@@ -0,0 +1,43 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestWindowHistogram(t *testing.T) {
+	h := NewWindowHistogram(100, []float64{10, 50, 90})
+	if !math.IsNaN(h.Quantile(0.5)) {
+		t.Error(h.Quantile(0.5))
+	}
+	// 1000 down to 1, the window keeps 100 down to 1.
+	for i := 1000; i > 0; i-- {
+		h.Observe(float64(i))
+	}
+	h.Observe(math.NaN())
+	if h.Count() != 100 {
+		t.Error(h.Count())
+	}
+	for _, c := range []struct{ q, want float64 }{
+		{0, 1}, {0.5, 50}, {0.9, 90}, {0.99, 99}, {1, 100},
+	} {
+		if got := h.Quantile(c.q); got != c.want {
+			t.Error(c.q, got)
+		}
+	}
+	if got := h.Buckets(nil); fmt.Sprint(got) != "[10 40 40 10]" {
+		t.Error(got)
+	}
+
+	// Allocation free once warmed up.
+	buckets := make([]uint64, 0, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		h.Observe(42)
+		h.Quantile(0.99)
+		buckets = h.Buckets(buckets[:0])
+	})
+	if allocs != 0 {
+		t.Error(allocs)
+	}
+}
@@ -0,0 +1,120 @@
+package circularbuffer
+
+// Observer callbacks, each called with the affected item. Any of them
+// may be nil. They run outside the lock, after the operation is done,
+// so they can use the buffer, but calls from concurrent operations may
+// arrive in any order.
+//
+// OnEvict sees every evicted item, whether or not the Evict callback
+// is set. Operations on a LockedBuffer don't run the hooks, as they
+// happen under the caller's lock, except for expired items, which are
+// reported once the lock is released.
+type Hooks[T any] struct {
+	OnPush  func(v T) // v was stored
+	OnGet   func(v T) // v was removed from the oldest end
+	OnPop   func(v T) // v was removed from the newest end
+	OnEvict func(v T) // v was evicted, or rejected by a full buffer
+
+	// v was evicted because its deadline passed, see PushTTL and
+	// WithRetention. Called before OnEvict, which sees it too.
+	OnExpire func(v T)
+
+	// v left the buffer for good and nobody else has it, e.g. to put
+	// it back to a sync.Pool. Called for evicted and expired items,
+	// after OnEvict and the Evict callback (which must not keep them),
+	// for items removed by RemoveIf or dropped by Reset and by
+	// UnmarshalJSON and friends, and for the items NewFromSlice or
+	// Recover had no room for.
+	//
+	// Not called for items handed out to the caller: taken by Get,
+	// Pop and friends, returned by a push or Resize, or moved out by
+	// DrainAll or Split, as the caller may still be using them. Pass
+	// them to Recycle when done.
+	OnRecycle func(v T)
+
+	spill *evictChan[T] // see EvictedChan
+}
+
+// Replace the hooks. Operations already past their critical section
+// still run the old ones.
+func (b *Buffer[T]) SetHooks(h Hooks[T]) {
+	b.lock.Lock()
+	h.spill = b.hooks.spill
+	b.hooks = h
+	b.lock.Unlock()
+}
+
+// Pass v, an item taken from the buffer, to the OnRecycle hook, if
+// set. The caller must not use v afterwards.
+func (b *Buffer[T]) Recycle(v T) {
+	b.lock.Lock()
+	recycle := b.hooks.OnRecycle
+	b.lock.Unlock()
+
+	if recycle != nil {
+		recycle(v)
+	}
+}
+
+// Set the hooks. Their item type must match the buffer's, use
+// Hooks[interface{}] for CircularBuffer.
+func WithHooks[T any](h Hooks[T]) Option {
+	return func(c *config) {
+		c.hooks = h
+	}
+}
+
+func (h *Hooks[T]) pushed(v T, stored bool, evicted []T) {
+	for _, evictv := range evicted {
+		h.evict(evictv)
+	}
+	if stored && h.OnPush != nil {
+		h.OnPush(v)
+	}
+}
+
+func (h *Hooks[T]) evicted(items []T) {
+	for _, v := range items {
+		h.evict(v)
+	}
+}
+
+func (h *Hooks[T]) evict(v T) {
+	if h.OnEvict != nil {
+		h.OnEvict(v)
+	}
+	if h.spill != nil {
+		h.spill.send(v)
+	}
+}
+
+// Run the Evict callback, if set, and then OnRecycle on items the
+// buffer let go of.
+func (h *Hooks[T]) handOff(evict func(v T), items ...T) {
+	for _, v := range items {
+		if evict != nil {
+			evict(v)
+		}
+		h.recycle(v)
+	}
+}
+
+func (h *Hooks[T]) recycle(items ...T) {
+	if h.OnRecycle != nil {
+		for _, v := range items {
+			h.OnRecycle(v)
+		}
+	}
+}
+
+func (h *Hooks[T]) taken(newest bool, items ...T) {
+	fn := h.OnGet
+	if newest {
+		fn = h.OnPop
+	}
+	if fn != nil {
+		for _, v := range items {
+			fn(v)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package circularbuffer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	var log []string
+	record := func(op string) func(v int) {
+		return func(v int) {
+			log = append(log, fmt.Sprint(op, v))
+		}
+	}
+	c := NewBuffer[int](3, WithHooks(Hooks[int]{
+		OnPush:  record("push"),
+		OnGet:   record("get"),
+		OnPop:   record("pop"),
+		OnEvict: record("evict"),
+	}))
+
+	c.NBPush(1)
+	c.PushAll([]int{2, 3})
+	c.Get()
+	c.Pop()
+	c.Push(4)
+	c.TryPush(5)
+	c.TryGet()
+	c.DrainAll()
+	c.NBPush(6)
+	c.ClaimBatch(context.Background(), 1)
+
+	want := "[push1 evict1 push2 push3 get2 pop3 push4 push5 get4 get5 push6 get6]"
+	if got := fmt.Sprint(log); got != want {
+		t.Error(got)
+	}
+
+	// Hooks can use the buffer.
+	length := -1
+	c.SetHooks(Hooks[int]{OnEvict: func(v int) {
+		length = c.Length()
+	}})
+	c.SetPolicy(DropNewest)
+	c.NBPush(1)
+	c.NBPush(2)
+	if v := c.NBPush(3); v != 3 || length != 2 {
+		t.Error(v, length)
+	}
+	c.Get()
+	c.Get()
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestRecycle(t *testing.T) {
+	pool := sync.Pool{New: func() interface{} { return new([64]byte) }}
+	var recycled []*[64]byte
+	now := time.Unix(100, 0)
+	c := NewBuffer[*[64]byte](3, WithClock(func() time.Time { return now }),
+		WithHooks(Hooks[*[64]byte]{OnRecycle: func(v *[64]byte) {
+			recycled = append(recycled, v)
+			pool.Put(v)
+		}}))
+
+	a, b, d := pool.Get().(*[64]byte), pool.Get().(*[64]byte), pool.Get().(*[64]byte)
+	c.NBPush(a)
+	c.NBPush(b)
+	// Returned to the caller, not recycled.
+	if v := c.NBPush(d); v != a || len(recycled) != 0 {
+		t.Error(v, recycled)
+	}
+	c.Recycle(a)
+
+	// Handed to the Evict callback first.
+	var evicted []*[64]byte
+	c.SetEvict(func(v *[64]byte) {
+		if len(recycled) > 0 && recycled[len(recycled)-1] == v {
+			t.Error(recycled)
+		}
+		evicted = append(evicted, v)
+	})
+	c.NBPush(pool.Get().(*[64]byte))
+	if len(evicted) != 1 || evicted[0] != b || len(recycled) != 2 || recycled[1] != b {
+		t.Error(evicted, recycled)
+	}
+
+	// Expired ones too.
+	c.PushTTL(pool.Get().(*[64]byte), time.Second)
+	now = now.Add(2 * time.Second)
+	if r := c.PopResult(); !r.OK || len(recycled) != 4 || len(evicted) != 3 {
+		t.Error(r, recycled, evicted)
+	}
+
+	c.NBPush(pool.Get().(*[64]byte))
+	c.Reset(false)
+	if len(recycled) != 5 || len(evicted) != 3 {
+		t.Error(recycled, evicted)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestRecycleDropped(t *testing.T) {
+	var recycled []int
+	hooks := WithHooks(Hooks[int]{OnRecycle: func(v int) {
+		recycled = append(recycled, v)
+	}})
+
+	// No room for the oldest.
+	c := NewFromSlice([]int{1, 2, 3, 4}, 4, hooks)
+	if fmt.Sprint(recycled) != "[1]" {
+		t.Error(recycled)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v%2 == 0 }); n != 2 {
+		t.Error(n)
+	}
+	if fmt.Sprint(recycled) != "[1 2 4]" {
+		t.Error(recycled)
+	}
+	c.NBPush(5)
+	if err := c.UnmarshalJSON([]byte(`{"size":4,"items":[6]}`)); err != nil {
+		t.Error(err)
+	}
+	if fmt.Sprint(recycled) != "[1 2 4 3 5]" {
+		t.Error(recycled)
+	}
+
+	// Handed out to the caller, not recycled.
+	c.NBPush(7)
+	c.NBPush(8)
+	c.Get()
+	c.Pop()
+	c.Split(1)
+	c.NBPush(9)
+	c.DrainAll()
+	if fmt.Sprint(recycled) != "[1 2 4 3 5]" {
+		t.Error(recycled)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,109 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Check the internal consistency of the buffer, returning a
+// description of the first violated invariant, or nil. Meant for tests
+// and for debugging; build with the circbufdebug tag to have it run
+// after every operation, panicking on violation.
+func (b *Buffer[T]) Verify() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.invariantsLocked()
+}
+
+// Checked invariants:
+//   - start and pos are valid indexes, at most size-1 cells are used
+//   - every unused cell (and its metadata, deadline and key) is zero,
+//     so the buffer doesn't keep references to items that were
+//     already consumed or evicted
+//   - every key maps to the cell holding it, every key is mapped
+//   - the total cost is the sum of the items' costs
+//   - the peak length is at least the length
+//   - reserved items count as claimed
+//   - the write-ahead log's view of the used cells is right
+//
+// Used cells may legitimately hold zero values, so they are not checked.
+// Must be called with the lock held.
+func (b *Buffer[T]) invariantsLocked() error {
+	where := func() string {
+		return fmt.Sprintf("(start=%d pos=%d size=%d)", b.start, b.pos, b.size)
+	}
+	if b.start > b.mask || b.pos > b.mask {
+		return fmt.Errorf("circularbuffer: index out of range %s", where())
+	}
+
+	used := (b.pos - b.start) & b.mask
+	if used >= b.size && b.buffer != nil {
+		return fmt.Errorf("circularbuffer: too many items %s", where())
+	}
+
+	for key, i := range b.keyCells {
+		if b.keys[i] != key {
+			return fmt.Errorf("circularbuffer: key %q maps to cell %d "+
+				"holding %q %s", key, i, b.keys[i], where())
+		}
+	}
+
+	cells := uint(len(b.buffer))
+	keys := 0
+	for n, i := uint(0), b.start; n < used; n, i = n+1, (i+1)&b.mask {
+		if b.keys != nil && b.keys[i] != "" {
+			keys++
+		}
+	}
+	if keys != len(b.keyCells) {
+		return fmt.Errorf("circularbuffer: %d keys in cells, %d mapped %s",
+			keys, len(b.keyCells), where())
+	}
+	for n, i := used, b.pos; n < cells; n, i = n+1, (i+1)&b.mask {
+		if !reflect.ValueOf(&b.buffer[i]).Elem().IsZero() {
+			return fmt.Errorf("circularbuffer: unused cell %d is not zero "+
+				"(value=%#v) %s", i, b.buffer[i], where())
+		}
+		if b.meta != nil && b.meta[i] != nil {
+			return fmt.Errorf("circularbuffer: unused meta cell %d is not nil "+
+				"(meta=%#v) %s", i, b.meta[i], where())
+		}
+		if b.keys != nil && b.keys[i] != "" {
+			return fmt.Errorf("circularbuffer: unused key cell %d is set "+
+				"(key=%q) %s", i, b.keys[i], where())
+		}
+		if b.expires != nil && !b.expires[i].IsZero() {
+			return fmt.Errorf("circularbuffer: unused deadline %d is set %s",
+				i, where())
+		}
+	}
+
+	if b.costOf != nil {
+		var cost int64
+		for n, i := uint(0), b.start; n < used; n, i = n+1, (i+1)&b.mask {
+			cost += b.costOf(b.buffer[i])
+		}
+		if cost != b.totalCost {
+			return fmt.Errorf("circularbuffer: total cost %d, items cost %d %s",
+				b.totalCost, cost, where())
+		}
+	}
+	if b.peak < used {
+		return fmt.Errorf("circularbuffer: peak length %d below length %s",
+			b.peak, where())
+	}
+	if len(b.reserved) > b.claimed {
+		return fmt.Errorf("circularbuffer: %d items reserved, %d claimed %s",
+			len(b.reserved), b.claimed, where())
+	}
+	if w := b.wal; w != nil && w.err == nil {
+		for i := uint(0); i < cells; i++ {
+			if w.used[i] != ((i-b.start)&b.mask < used) {
+				return fmt.Errorf("circularbuffer: write-ahead log has "+
+					"cell %d used=%v %s", i, w.used[i], where())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package circularbuffer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	c := NewBuffer[int](4, WithMaxCost(100, func(v int) int64 { return int64(v) }))
+	c.NBPush(1)
+	c.NBPush(2)
+	if err := c.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	c.lock.Lock()
+	c.totalCost++
+	c.lock.Unlock()
+	if err := c.Verify(); err == nil || !strings.Contains(err.Error(), "total cost 4") {
+		t.Error(err)
+	}
+	c.lock.Lock()
+	c.totalCost--
+	c.buffer[c.pos] = 7
+	c.lock.Unlock()
+	if err := c.Verify(); err == nil || !strings.Contains(err.Error(), "is not zero") {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,60 @@
+package circularbuffer
+
+import (
+	"iter"
+)
+
+// Iterate over items oldest-first, removing each one as it is yielded,
+// until the buffer is empty. Breaking out of the loop leaves the
+// remaining items in the buffer. The lock is not held while the loop
+// body runs, so it may use the buffer freely.
+func (b *Buffer[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			r := b.GetResult()
+			if !r.OK || !yield(r.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Call fn for every item, oldest first, with its index, until fn
+// returns false. Nothing is removed. The lock is held for the whole
+// iteration, so fn must be quick and must not use the buffer.
+func (b *Buffer[T]) Range(fn func(i int, v T) bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	used := int(b.used())
+	for i := 0; i < used; i++ {
+		if !fn(i, b.buffer[(b.start+uint(i))&b.mask]) {
+			return
+		}
+	}
+}
+
+// Iterate over a snapshot of the items, oldest first. The snapshot is
+// taken when the iteration starts, so the lock is not held while the
+// loop body runs and nothing is removed.
+func (b *Buffer[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range b.Snapshot() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Like All, but newest first.
+func (b *Buffer[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		items := b.Snapshot()
+		for i := len(items) - 1; i >= 0; i-- {
+			if !yield(items[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	for i := 0; i < 6; i++ {
+		c.NBPush(i)
+	}
+
+	i := 0
+	for v := range c.Drain() {
+		if v != i {
+			t.Error(v)
+		}
+		if i == 2 {
+			break
+		}
+		i++
+	}
+
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+
+	i = 3
+	for v := range c.Drain() {
+		if v != i {
+			t.Error(v)
+		}
+		// Pushing from the loop body must not deadlock.
+		if v == 3 {
+			c.NBPush(6)
+		}
+		i++
+	}
+	if i != 7 {
+		t.Error(i)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestRange(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+
+	var seen []int
+	c.Range(func(i int, v int) bool {
+		if v != i+2 {
+			t.Error(i, v)
+		}
+		seen = append(seen, v)
+		return true
+	})
+	if len(seen) != 3 {
+		t.Error(seen)
+	}
+
+	seen = nil
+	c.Range(func(i int, v int) bool {
+		seen = append(seen, v)
+		return i < 1
+	})
+	if len(seen) != 2 || seen[0] != 2 || seen[1] != 3 {
+		t.Error(seen)
+	}
+
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+}
+
+func TestAllBackward(t *testing.T) {
+	c := NewBuffer[int](4)
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+
+	var seen []int
+	for v := range c.All() {
+		// The lock is not held, the loop body may use the buffer.
+		c.NBPush(v + 10)
+		seen = append(seen, v)
+	}
+	if len(seen) != 3 || seen[0] != 2 || seen[2] != 4 {
+		t.Error(seen)
+	}
+
+	seen = nil
+	for v := range c.Backward() {
+		seen = append(seen, v)
+		if len(seen) == 2 {
+			break
+		}
+	}
+	if len(seen) != 2 || seen[0] != 14 || seen[1] != 13 {
+		t.Error(seen)
+	}
+
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+}
@@ -0,0 +1,97 @@
+package circularbuffer
+
+// Nonblocking push of v under key, conflating updates: if an item
+// with the same key is still queued it's replaced by v in place,
+// keeping its position (unless WithAccessOrder is set), otherwise v is
+// pushed as by NBPush. Meant for
+// state-update queues where only the latest value per key matters.
+//
+// The replaced item counts as evicted, like the one pushed out of a
+// full buffer: it's passed to the Evict callback or returned. An empty
+// key never matches, the item is pushed as by NBPush.
+func (b *Buffer[T]) PushKeyed(key string, v T) T {
+	var dedup string
+	if b.dedupKey != nil {
+		dedup = b.dedupKey(v)
+	}
+	var evictbuf [1]T
+	evicted := evictbuf[:0]
+	stored := true
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	i, found := b.keyCells[key]
+	if found && key != "" && !b.closed {
+		old := b.buffer[i]
+		b.clearCell(i)
+		b.setCell(i, v)
+		b.setKeyLocked(i, key)
+		if b.accessOrder {
+			b.moveToNewestLocked(i)
+		}
+		b.pushed++
+		b.noteEvictionLocked()
+		if b.recording {
+			b.oplog = append(b.oplog, Op{Kind: OpPushKeyed, Value: KeyedOp{key, v}})
+		}
+		evicted = append(evicted, old)
+		for b.costOf != nil && b.totalCost > b.maxCost && b.start != b.pos {
+			evicted = append(evicted, b.evictOldestLocked())
+		}
+	} else {
+		b.waitOverflowLocked()
+		n := len(b.oplog)
+		var err error
+		evicted, stored, err = b.pushLocked(v, dedup, evicted)
+		if len(b.oplog) > n && key != "" {
+			// Replay has to attach the key too.
+			b.oplog[n] = Op{Kind: OpPushKeyed, Value: KeyedOp{key, v}}
+		}
+		if err == errPushPanic {
+			b.lock.Unlock()
+			panic("circularbuffer: push to closed buffer")
+		}
+		if err != nil {
+			evict, hooks := b.Evict, b.hooks
+			b.lock.Unlock()
+			reportExpired(expired, evict, &hooks)
+			return v
+		}
+		if stored && key != "" {
+			b.setKeyLocked((b.pos-1)&b.mask, key)
+		}
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.pushed(v, stored, evicted)
+	var zero T
+	if len(evicted) == 0 {
+		return zero
+	}
+	if evict != nil {
+		hooks.handOff(evict, evicted...)
+		return zero
+	}
+	return evicted[0]
+}
+
+// Attach key to the item in cell i. Must be called with the lock held.
+func (b *Buffer[T]) setKeyLocked(i uint, key string) {
+	if b.keys == nil {
+		b.keys = make([]string, len(b.buffer))
+		b.keyCells = make(map[string]uint)
+	}
+	b.keys[i] = key
+	b.keyCells[key] = i
+}
+
+// Detach the key, if any, from the item in cell i. Must be called with
+// the lock held.
+func (b *Buffer[T]) dropKey(i uint) {
+	if b.keys != nil && b.keys[i] != "" {
+		delete(b.keyCells, b.keys[i])
+		b.keys[i] = ""
+	}
+}
@@ -0,0 +1,57 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPushKeyed(t *testing.T) {
+	c := NewBuffer[string](5)
+	c.PushKeyed("a", "a1")
+	c.PushKeyed("b", "b1")
+	c.NBPush("x")
+	// Replaced in place.
+	if v := c.PushKeyed("a", "a2"); v != "a1" {
+		t.Error(v)
+	}
+	c.PushKeyed("", "y")
+	if got := c.Snapshot(); fmt.Sprint(got) != "[a2 b1 x y]" {
+		t.Error(got)
+	}
+
+	// Once consumed, the key starts a new item.
+	if v := c.Get(); v != "a2" {
+		t.Error(v)
+	}
+	c.PushKeyed("a", "a3")
+	// Full, b1 is evicted and its key forgotten.
+	if v := c.PushKeyed("c", "c1"); v != "b1" {
+		t.Error(v)
+	}
+	c.PushKeyed("b", "b2") // evicts x
+	c.PushKeyed("a", "a4")
+	if got := c.Snapshot(); fmt.Sprint(got) != "[y a4 c1 b2]" {
+		t.Error(got)
+	}
+
+	// Keys survive moving the items around.
+	c.RemoveIf(func(v string) bool { return v == "y" })
+	c.Resize(9)
+	c.PushKeyed("c", "c2")
+	d := c.Clone()
+	d.PushKeyed("b", "b3")
+	if got := c.Snapshot(); fmt.Sprint(got) != "[a4 c2 b2]" {
+		t.Error(got)
+	}
+	if got := d.DrainAll(); fmt.Sprint(got) != "[a4 c2 b3]" {
+		t.Error(got)
+	}
+	if s := c.Stats(); s.Pushed != 10 || s.Evicted != 5 {
+		t.Error(s)
+	}
+	c.DrainAll()
+
+	if c.verifyIsEmpty() != true || d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,117 @@
+package circularbuffer
+
+import (
+	"sync"
+	"time"
+)
+
+// Per-producer rings feeding a central Buffer. Each producer pushes to
+// its own LocalBuffer, an SPSCBuffer, with no lock shared with other
+// producers. A background combiner drains all of them into the central
+// buffer every interval, one PushAll per local ring.
+//
+// Items of one producer keep their order, but items from different
+// producers are interleaved per flush, not in push order, and reach
+// consumers up to an interval late.
+type LocalBuffers[T any] struct {
+	central *Buffer[T]
+	size    uint
+
+	mu     sync.Mutex // held while draining, the combiner is the consumer side
+	locals []*LocalBuffer[T]
+	batch  []T
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Producer's private ring. Push must only be called from one goroutine
+// at a time.
+type LocalBuffer[T any] struct {
+	parent *LocalBuffers[T]
+	ring   *SPSCBuffer[T]
+}
+
+// Create LocalBuffers draining into central every interval. Each local
+// ring holds localSize items, rounded up to a power of two. Call Close
+// to stop the combiner.
+func NewLocalBuffers[T any](central *Buffer[T], localSize uint, interval time.Duration) *LocalBuffers[T] {
+	l := &LocalBuffers[T]{
+		central: central,
+		size:    localSize,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.combine(interval)
+	return l
+}
+
+// New private ring for a producer. It lives as long as the
+// LocalBuffers, so get one per producer goroutine, not per push.
+func (l *LocalBuffers[T]) Local() *LocalBuffer[T] {
+	lb := &LocalBuffer[T]{parent: l, ring: NewSPSCBuffer[T](l.size)}
+	l.mu.Lock()
+	l.locals = append(l.locals, lb)
+	l.mu.Unlock()
+	return lb
+}
+
+// Push v to the local ring. If it's full, because the producer is
+// faster than the combiner, v goes straight to the central buffer,
+// ahead of the older items still in the local ring.
+func (lb *LocalBuffer[T]) Push(v T) {
+	if lb.ring.TryPush(v) != nil {
+		lb.parent.central.NBPush(v)
+	}
+}
+
+// Drain all the local rings into the central buffer now.
+func (l *LocalBuffers[T]) Flush() {
+	l.mu.Lock()
+	for _, lb := range l.locals {
+		batch := l.batch[:0]
+		for {
+			v, ok := lb.ring.TryGet()
+			if !ok {
+				break
+			}
+			batch = append(batch, v)
+		}
+		if len(batch) > 0 {
+			l.central.PushAll(batch)
+		}
+		// Don't keep the items alive in the scratch slice.
+		var zero T
+		for i := range batch {
+			batch[i] = zero
+		}
+		l.batch = batch
+	}
+	l.mu.Unlock()
+}
+
+// Stop the combiner and flush what's left. Pushes after Close stay in
+// the local rings until the next Flush.
+func (l *LocalBuffers[T]) Close() {
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+	<-l.done
+	l.Flush()
+}
+
+func (l *LocalBuffers[T]) combine(interval time.Duration) {
+	defer close(l.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			l.Flush()
+		case <-l.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package circularbuffer
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocalBuffers(t *testing.T) {
+	c := NewBuffer[int](100)
+	l := NewLocalBuffers(c, 3, time.Hour) // rounded up to 4
+	a, b := l.Local(), l.Local()
+
+	a.Push(1)
+	b.Push(10)
+	a.Push(2)
+	if c.Length() != 0 {
+		t.Error(c.Length())
+	}
+	l.Flush()
+	// Grouped per local ring, in order within each.
+	if got := c.DrainAll(); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 10 {
+		t.Error(got)
+	}
+
+	// A full local ring overflows straight into the central buffer.
+	for i := 1; i <= 5; i++ {
+		a.Push(i)
+	}
+	if v := c.Get(); v != 5 {
+		t.Error(v)
+	}
+	l.Close()
+	l.Close()
+	if got := c.DrainAll(); len(got) != 4 || got[0] != 1 || got[3] != 4 {
+		t.Error(got)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestLocalBuffersConcurrent(t *testing.T) {
+	const producers, n = 4, 1000
+	c := NewBuffer[int](producers*n + 1)
+	l := NewLocalBuffers(c, 64, time.Millisecond)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			lb := l.Local()
+			for i := 0; i < n; i++ {
+				lb.Push(p*n + i)
+			}
+		}(p)
+	}
+	wg.Wait()
+	l.Close()
+
+	seen := make(map[int]bool)
+	for _, v := range c.DrainAll() {
+		seen[v] = true
+	}
+	if len(seen) != producers*n {
+		t.Error(len(seen))
+	}
+}
@@ -0,0 +1,86 @@
+package circularbuffer
+
+// Handle to a buffer whose lock is held by the caller, passed to the
+// function given to IfLengthAtLeast. It is only valid until that
+// function returns.
+//
+// Re-entrancy rules: while the lock is held the function must use
+// only the handle. Calling any method on the Buffer itself,
+// directly or from another goroutine the function waits for, will
+// deadlock. NBPush on the handle doesn't call the Evict callback under
+// the lock, as the callback might use the buffer; the evicted item is
+// returned instead. Expired items skipped by Get and Pop, and any
+// further items evicted by one NBPush, are passed to the hooks and the
+// Evict callback once the lock is released.
+type LockedBuffer[T any] struct {
+	b       *Buffer[T]
+	expired []T
+	evicted []T
+}
+
+// Check under the lock whether the buffer holds at least n items and
+// if so call fn while still holding it, so that the check and the
+// action are atomic. Returns whether fn was called.
+func (b *Buffer[T]) IfLengthAtLeast(n int, fn func(l *LockedBuffer[T])) bool {
+	l := &LockedBuffer[T]{b: b}
+	b.lock.Lock()
+	// fn can't change these, it would deadlock.
+	evict, hooks := b.Evict, b.hooks
+	called := func() bool {
+		defer b.lock.Unlock()
+		if int(b.used()) < n {
+			return false
+		}
+		defer func() { l.b = nil }()
+		fn(l)
+		return true
+	}()
+
+	reportExpired(l.expired, evict, &hooks)
+	hooks.evicted(l.evicted)
+	hooks.handOff(evict, l.evicted...)
+	return called
+}
+
+// Length of the buffer.
+func (l *LockedBuffer[T]) Length() int {
+	return int(l.b.used())
+}
+
+// Nonblocking push, returns the evicted item (if any). If several
+// items were evicted the oldest one is returned and the rest are
+// reported after the lock is released. With the Block or Error policy
+// a full buffer is left intact and v is returned.
+func (l *LockedBuffer[T]) NBPush(v T) T {
+	var key string
+	if l.b.dedupKey != nil {
+		key = l.b.dedupKey(v)
+	}
+	var evictbuf [1]T
+	evicted, _, err := l.b.pushLocked(v, key, evictbuf[:0])
+	if err == errPushPanic {
+		panic("circularbuffer: push to closed buffer")
+	}
+	l.b.checkInvariants()
+	if err != nil {
+		return v
+	}
+	if len(evicted) == 0 {
+		var zero T
+		return zero
+	}
+	l.evicted = append(l.evicted, evicted[1:]...)
+	return evicted[0]
+}
+
+// Get the oldest item, ok is false if the buffer is empty. Expired
+// items are skipped and evicted, as in Buffer.Get.
+func (l *LockedBuffer[T]) Get() (v T, ok bool) {
+	return l.b.takeLiveLocked(false, &l.expired)
+}
+
+// Pop the newest item, ok is false if the buffer is empty. Expired
+// items are skipped and evicted, as in Buffer.Pop.
+func (l *LockedBuffer[T]) Pop() (v T, ok bool) {
+	return l.b.takeLiveLocked(true, &l.expired)
+}
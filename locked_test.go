@@ -0,0 +1,96 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestIfLengthAtLeast(t *testing.T) {
+	c := NewCircularBuffer(10)
+
+	flush := func(l *LockedBuffer[interface{}]) {
+		for {
+			if _, ok := l.Get(); !ok {
+				break
+			}
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		c.NBPush(i)
+		if c.IfLengthAtLeast(4, flush) {
+			t.Error("flushed below the threshold")
+		}
+	}
+	if c.Length() != 3 {
+		t.Error(c.Length())
+	}
+
+	c.NBPush(3)
+	if !c.IfLengthAtLeast(4, flush) {
+		t.Error("not flushed at the threshold")
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestLockedBuffer(t *testing.T) {
+	c := NewCircularBuffer(3)
+
+	c.IfLengthAtLeast(0, func(l *LockedBuffer[interface{}]) {
+		l.NBPush(0)
+		l.NBPush(1)
+		if v := l.NBPush(2); v != 0 {
+			t.Error(v)
+		}
+		if l.Length() != 2 {
+			t.Error(l.Length())
+		}
+		if v, ok := l.Pop(); !ok || v != 2 {
+			t.Error(v, ok)
+		}
+		if v, ok := l.Get(); !ok || v != 1 {
+			t.Error(v, ok)
+		}
+		if v, ok := l.Get(); ok {
+			t.Error(v, ok)
+		}
+	})
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestLockedBufferEvictMany(t *testing.T) {
+	c := NewCostBoundedBuffer(3, func(v interface{}) int64 {
+		return int64(v.(int))
+	})
+	var evicted []interface{}
+	c.Evict = func(v interface{}) {
+		evicted = append(evicted, v)
+		if c.Length() != 1 {
+			t.Error(c.Length())
+		}
+	}
+
+	c.IfLengthAtLeast(0, func(l *LockedBuffer[interface{}]) {
+		l.NBPush(1)
+		l.NBPush(2)
+		if v := l.NBPush(3); v != 1 {
+			t.Error(v)
+		}
+		if len(evicted) != 0 {
+			t.Error(evicted)
+		}
+	})
+	if len(evicted) != 1 || evicted[0] != 2 {
+		t.Error(evicted)
+	}
+
+	c.Get()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,233 @@
+package circularbuffer
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Lock-free multi-producer multi-consumer ring of items. Slots carry
+// sequence numbers and producers and consumers claim them with CAS
+// (Dmitry Vyukov's bounded queue), so no mutex is taken on the push
+// or get path. Eviction works as in Buffer: pushing to a full ring
+// evicts the oldest item.
+//
+// It's a FIFO only: taking the newest item can't be done safely
+// without a lock, so there is no Pop and it satisfies StackPusher but
+// not StackGetter. Length is approximate under concurrent use.
+type LockFreeBuffer[T any] struct {
+	cells  []lockFreeCell[T]
+	mask   uint64
+	notify chan struct{} // wakes up a blocked consumer
+
+	// Called with every evicted item, instead of returning it from
+	// NBPush. Must be set before the buffer is shared.
+	Evict func(v T)
+
+	// Producers hammer head, consumers tail, keep them on separate
+	// cache lines and away from the read-only fields above.
+	_       cacheLinePad
+	head    atomic.Uint64 // next slot to push to
+	_       cacheLinePad
+	tail    atomic.Uint64 // next slot to get from
+	_       cacheLinePad
+	waiters atomic.Int32  // consumers blocked in Get
+	evicted atomic.Uint64 // only touched on overflow
+	_       cacheLinePad
+}
+
+// Assumed size of a CPU cache line. 64 bytes on amd64 and most arm64.
+const cacheLineSize = 64
+
+// Padding keeping the fields around it on different cache lines, so
+// that goroutines on different cores updating them don't invalidate
+// each other's caches (false sharing).
+type cacheLinePad struct {
+	_ [cacheLineSize]byte
+}
+
+type lockFreeCell[T any] struct {
+	seq atomic.Uint64
+	v   T
+}
+
+// Set in the sequence number of a published cell while a consumer or
+// PeekAll reads it, so that the two never touch v at the same time.
+const cellBusy = 1 << 63
+
+var _ StackPusher = (*LockFreeBuffer[interface{}])(nil)
+
+// Create LockFreeBuffer holding up to size items. The size is rounded
+// up to a power of two.
+func NewLockFreeBuffer[T any](size uint) *LockFreeBuffer[T] {
+	n := uint64(ringSize(size))
+	b := &LockFreeBuffer[T]{
+		cells:  make([]lockFreeCell[T], n),
+		mask:   n - 1,
+		notify: make(chan struct{}, 1),
+	}
+	for i := range b.cells {
+		b.cells[i].seq.Store(uint64(i))
+	}
+	return b
+}
+
+// Nonblocking push. If the Evict callback is not set returns the
+// evicted item (if any), otherwise nil (zero value). When several
+// producers race for the last free slot a push may evict more than one
+// item, NBPush then returns only the oldest one; set the Evict
+// callback to see all of them.
+func (b *LockFreeBuffer[T]) NBPush(v T) T {
+	var first T
+	evicted := false
+	for !b.enqueue(v) {
+		evictv, ok := b.dequeue()
+		if !ok {
+			// Another goroutine is busy with the slot we need.
+			runtime.Gosched()
+			continue
+		}
+		b.evicted.Add(1)
+		if b.Evict != nil {
+			b.Evict(evictv)
+		} else if !evicted {
+			first, evicted = evictv, true
+		}
+	}
+	if b.waiters.Load() > 0 {
+		b.wakeup()
+	}
+	return first
+}
+
+// Get the oldest item, blocking.
+func (b *LockFreeBuffer[T]) Get() T {
+	for {
+		v, ok := b.dequeue()
+		if !ok {
+			b.waiters.Add(1)
+			// Check again, a push may have missed us as a
+			// waiter.
+			if v, ok = b.dequeue(); !ok {
+				<-b.notify
+			}
+			b.waiters.Add(-1)
+		}
+		if ok {
+			if b.waiters.Load() > 0 && b.Length() > 0 {
+				// More items left, pass the wakeup on to
+				// the next blocked consumer.
+				b.wakeup()
+			}
+			return v
+		}
+	}
+}
+
+// Get the oldest item without blocking. ok is false if the buffer is
+// empty.
+func (b *LockFreeBuffer[T]) TryGet() (v T, ok bool) {
+	return b.dequeue()
+}
+
+// Number of items in the buffer.
+func (b *LockFreeBuffer[T]) Length() int {
+	// Load tail first, so that the result is never negative.
+	tail := b.tail.Load()
+	head := b.head.Load()
+	return int(head - tail)
+}
+
+// Best-effort snapshot of the items, oldest first, for monitoring. Can
+// be called from any goroutine. Only slots published at the time they
+// are visited are read, so the result may be slightly stale and miss
+// items pushed or taken meanwhile, but never holds an item that wasn't
+// pushed. Each slot is held for the time of one copy, a consumer
+// taking that item waits for it.
+func (b *LockFreeBuffer[T]) PeekAll() []T {
+	tail := b.tail.Load()
+	head := b.head.Load()
+	if head-tail > uint64(len(b.cells)) {
+		// Lapped while loading, only the last lap can be there.
+		tail = head - uint64(len(b.cells))
+	}
+	items := make([]T, 0, head-tail)
+	for pos := tail; pos != head; pos++ {
+		c := &b.cells[pos&b.mask]
+		if !c.seq.CompareAndSwap(pos+1, (pos+1)|cellBusy) {
+			// Not published yet, or being taken.
+			continue
+		}
+		items = append(items, c.v)
+		c.seq.Store(pos + 1)
+	}
+	return items
+}
+
+// Is the buffer empty?
+func (b *LockFreeBuffer[T]) Empty() bool {
+	return b.Length() == 0
+}
+
+// Maximum number of items the buffer can hold.
+func (b *LockFreeBuffer[T]) Cap() int {
+	return len(b.cells)
+}
+
+func (b *LockFreeBuffer[T]) wakeup() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Store v in the next free slot. Returns false if the buffer is full.
+func (b *LockFreeBuffer[T]) enqueue(v T) bool {
+	pos := b.head.Load()
+	for {
+		c := &b.cells[pos&b.mask]
+		seq := c.seq.Load() &^ cellBusy
+		switch dif := int64(seq - pos); {
+		case dif == 0:
+			if b.head.CompareAndSwap(pos, pos+1) {
+				c.v = v
+				// Publish the item to consumers.
+				c.seq.Store(pos + 1)
+				return true
+			}
+		case dif < 0:
+			// The slot still holds an item from the previous lap.
+			return false
+		}
+		pos = b.head.Load()
+	}
+}
+
+// Take the item from the oldest slot. Returns false if the buffer is
+// empty.
+func (b *LockFreeBuffer[T]) dequeue() (v T, ok bool) {
+	pos := b.tail.Load()
+	for {
+		c := &b.cells[pos&b.mask]
+		seq := c.seq.Load() &^ cellBusy
+		switch dif := int64(seq - (pos + 1)); {
+		case dif == 0:
+			if b.tail.CompareAndSwap(pos, pos+1) {
+				// Wait for PeekAll to let go of the slot.
+				for !c.seq.CompareAndSwap(pos+1, (pos+1)|cellBusy) {
+					runtime.Gosched()
+				}
+				v = c.v
+				var zero T
+				c.v = zero
+				// Hand the slot over to the producers of
+				// the next lap.
+				c.seq.Store(pos + b.mask + 1)
+				return v, true
+			}
+		case dif < 0:
+			// Not published yet.
+			return v, false
+		}
+		pos = b.tail.Load()
+	}
+}
@@ -0,0 +1,225 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestLockFreeBuffer(t *testing.T) {
+	c := NewLockFreeBuffer[int](3) // rounded up to 4
+	if c.Cap() != 4 {
+		t.Error(c.Cap())
+	}
+	if _, ok := c.TryGet(); ok {
+		t.Error("not empty")
+	}
+
+	for i := 1; i <= 4; i++ {
+		if v := c.NBPush(i); v != 0 {
+			t.Error(v)
+		}
+	}
+	if v := c.NBPush(5); v != 1 {
+		t.Error(v)
+	}
+	var evicted []int
+	c.Evict = func(v int) {
+		evicted = append(evicted, v)
+	}
+	if v := c.NBPush(6); v != 0 || len(evicted) != 1 || evicted[0] != 2 {
+		t.Error(v, evicted)
+	}
+
+	if c.Length() != 4 {
+		t.Error(c.Length())
+	}
+	for i := 3; i <= 6; i++ {
+		if v := c.Get(); v != i {
+			t.Error(v)
+		}
+	}
+	if !c.Empty() {
+		t.Error(c.Length())
+	}
+}
+
+func TestLockFreeBufferConcurrent(t *testing.T) {
+	const producers, consumers, n = 8, 4, 2000
+	c := NewLockFreeBuffer[int](64)
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	c.Evict = func(v int) {
+		mu.Lock()
+		seen[v] = true
+		mu.Unlock()
+	}
+
+	var cwg sync.WaitGroup
+	for i := 0; i < consumers; i++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			for {
+				v := c.Get()
+				if v < 0 {
+					return
+				}
+				mu.Lock()
+				if seen[v] {
+					t.Error("duplicate", v)
+				}
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var pwg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		pwg.Add(1)
+		go func(p int) {
+			defer pwg.Done()
+			for i := 0; i < n; i++ {
+				c.NBPush(p*n + i)
+			}
+		}(p)
+	}
+	pwg.Wait()
+
+	// Wait for the consumers to catch up, so that the stop values
+	// don't evict anything.
+	for !c.Empty() {
+		runtime.Gosched()
+	}
+	for i := 0; i < consumers; i++ {
+		c.NBPush(-1)
+	}
+	cwg.Wait()
+
+	// Every item was either consumed or evicted, exactly once.
+	if len(seen) != producers*n {
+		t.Error(len(seen))
+	}
+}
+
+func TestLockFreeBufferPeekAll(t *testing.T) {
+	const producers, n = 4, 200
+	c := NewLockFreeBuffer[int](producers * n)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 1; i <= n; i++ {
+				c.NBPush(p*n + i)
+				runtime.Gosched()
+			}
+		}(p)
+	}
+
+	check := func(items []int) {
+		last := make(map[int]int)
+		for _, v := range items {
+			// Only pushed items, each producer's in order.
+			p := (v - 1) / n
+			if v < 1 || v > producers*n || v <= last[p] {
+				t.Error(items)
+				return
+			}
+			last[p] = v
+		}
+	}
+	for c.Length() < producers*n {
+		check(c.PeekAll())
+		runtime.Gosched()
+	}
+	wg.Wait()
+	if items := c.PeekAll(); len(items) != producers*n {
+		t.Error(len(items))
+	} else {
+		check(items)
+	}
+}
+
+func TestLockFreeBufferPeekAllChurn(t *testing.T) {
+	// Snapshots while producers evict and a consumer takes items, run
+	// with -race. Strings would tear if a slot were read while emptied.
+	const producers, n = 2, 2000
+	c := NewLockFreeBuffer[string](8)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				c.NBPush(fmt.Sprintf("item-%d-%d", p, i))
+			}
+		}(p)
+	}
+	stop := make(chan struct{})
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			c.TryGet()
+			runtime.Gosched()
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		for _, v := range c.PeekAll() {
+			var p, i int
+			if _, err := fmt.Sscanf(v, "item-%d-%d", &p, &i); err != nil || p >= producers || i >= n {
+				t.Fatal(v)
+			}
+		}
+		select {
+		case <-done:
+			close(stop)
+			<-consumed
+			return
+		default:
+			runtime.Gosched()
+		}
+	}
+}
+
+func BenchmarkLockFreeProducerConsumer(b *testing.B) {
+	for _, p := range []int{1, 4, 16} {
+		for _, c := range []int{1, 4} {
+			for _, size := range []uint{16, 1024} {
+				name := fmt.Sprintf("p%d-c%d-size%d", p, c, size)
+				b.Run(name, func(b *testing.B) {
+					benchmarkProducerConsumer(b, NewLockFreeBuffer[interface{}](size), p, c)
+				})
+			}
+		}
+	}
+}
+
+func TestLockFreeBufferLayout(t *testing.T) {
+	var b LockFreeBuffer[int]
+	head := unsafe.Offsetof(b.head)
+	tail := unsafe.Offsetof(b.tail)
+	waiters := unsafe.Offsetof(b.waiters)
+	if tail-head < cacheLineSize || waiters-tail < cacheLineSize {
+		t.Error(head, tail, waiters)
+	}
+	if head-unsafe.Offsetof(b.Evict) < cacheLineSize {
+		t.Error(head)
+	}
+}
@@ -0,0 +1,111 @@
+package circularbuffer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// io.Writer keeping only the most recent lines written to it, e.g. to
+// have the context of a crash at hand without logs growing without
+// bound. Input is split on '\n'; an unterminated last line is held
+// back until its newline arrives, but Dump includes it. With a byte
+// limit only its last maxBytes bytes are held, e.g. of a progress bar
+// redrawn with '\r'. Safe for concurrent use.
+type LogRing struct {
+	lock     sync.Mutex // serializes writes, guards partial
+	lines    *Buffer[string]
+	maxLines int
+	maxBytes int64
+	partial  []byte
+	cut      bool // partial lost its head, too long to keep
+}
+
+var _ io.Writer = (*LogRing)(nil)
+
+// Create LogRing keeping up to maxLines lines and, if maxBytes is
+// positive, up to maxBytes bytes of them, newlines not counted. A line
+// longer than maxBytes is dropped.
+func NewLogRing(maxLines uint, maxBytes int64) *LogRing {
+	r := &LogRing{maxLines: int(maxLines), maxBytes: maxBytes}
+	if maxBytes > 0 {
+		r.lines = NewBuffer[string](costBoundedInitialSize,
+			WithMaxCost(maxBytes, func(line string) int64 {
+				return int64(len(line))
+			}))
+	} else {
+		r.lines = NewBuffer[string](maxLines + 1)
+	}
+	return r
+}
+
+// Append p, evicting the oldest lines as needed. Never fails.
+func (r *LogRing) Write(p []byte) (int, error) {
+	n := len(p)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		var line string
+		if len(r.partial) > 0 {
+			line = string(append(r.partial, p[:i]...))
+			r.partial = r.partial[:0]
+		} else {
+			line = string(p[:i])
+		}
+		if !r.cut {
+			r.push(line)
+		}
+		r.cut = false
+		p = p[i+1:]
+	}
+	r.partial = append(r.partial, p...)
+	if r.maxBytes > 0 && int64(len(r.partial)) > r.maxBytes {
+		// The line is too long to keep anyway, hold only the tail
+		// for Dump.
+		r.partial = append(r.partial[:0], r.partial[int64(len(r.partial))-r.maxBytes:]...)
+		r.cut = true
+	}
+	return n, nil
+}
+
+func (r *LogRing) push(line string) {
+	if r.maxLines == 0 || r.maxBytes > 0 && int64(len(line)) > r.maxBytes {
+		// It would push out everything, including itself.
+		return
+	}
+	r.lines.NBPush(line)
+	// A cost bounded buffer doesn't limit the number of items.
+	for r.lines.Length() > r.maxLines {
+		r.lines.TryGet()
+	}
+}
+
+// Write the retained lines to w, oldest first, followed by the
+// unterminated last line if there is one. The lines stay in the ring.
+func (r *LogRing) Dump(w io.Writer) error {
+	r.lock.Lock()
+	partial := string(r.partial)
+	lines := r.lines.Snapshot()
+	r.lock.Unlock()
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	if partial != "" {
+		if _, err := io.WriteString(w, partial); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Number of complete lines retained.
+func (r *LogRing) Length() int {
+	return r.lines.Length()
+}
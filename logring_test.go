@@ -0,0 +1,58 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogRing(t *testing.T) {
+	r := NewLogRing(3, 0)
+	fmt.Fprint(r, "one\ntwo\nthr")
+	fmt.Fprint(r, "ee\nfour\nfive\nsix")
+	var sb strings.Builder
+	if err := r.Dump(&sb); err != nil || sb.String() != "three\nfour\nfive\nsix" {
+		t.Errorf("%q %v", sb.String(), err)
+	}
+	if r.Length() != 3 {
+		t.Error(r.Length())
+	}
+
+	// As a log.Logger output, limited by bytes.
+	r = NewLogRing(100, 10)
+	l := log.New(r, "", 0)
+	l.Print("aaaa")
+	l.Print("bbbb")
+	l.Print("cccc")
+	l.Print("this line is too long")
+	sb.Reset()
+	r.Dump(&sb)
+	if sb.String() != "bbbb\ncccc\n" {
+		t.Errorf("%q", sb.String())
+	}
+}
+
+func TestLogRingPartial(t *testing.T) {
+	r := NewLogRing(4, 8)
+	r.Write([]byte("done\n"))
+	// A progress bar never ends its line.
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(r, "\r%04d", i)
+	}
+	if len(r.partial) > 8 {
+		t.Error(len(r.partial))
+	}
+	var sb strings.Builder
+	r.Dump(&sb)
+	if sb.String() != "done\n998\r0999" {
+		t.Errorf("%q", sb.String())
+	}
+	// Once it ends, it's too long to keep.
+	r.Write([]byte("\nok\n"))
+	sb.Reset()
+	r.Dump(&sb)
+	if sb.String() != "done\nok\n" {
+		t.Errorf("%q", sb.String())
+	}
+}
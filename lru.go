@@ -0,0 +1,53 @@
+package circularbuffer
+
+// Keep keyed items in access order: Lookup and PushKeyed replacing an
+// item move it to the newest end. As overflow evicts the oldest item,
+// a buffer filled with PushKeyed becomes a bounded LRU cache, with
+// the Evict callback seeing what falls out. Moving an item shifts the
+// newer ones, so it's meant for small caches.
+func WithAccessOrder() Option {
+	return func(c *config) {
+		c.accessOrder = true
+	}
+}
+
+// Value of the item pushed under key with PushKeyed, without removing
+// it. ok is false if there is none, or it has expired. With
+// WithAccessOrder the item becomes the newest one.
+func (b *Buffer[T]) Lookup(key string) (v T, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	i, ok := b.keyCells[key]
+	if !ok || key == "" {
+		return v, false
+	}
+	if b.expires != nil {
+		if deadline := b.expires[i]; !deadline.IsZero() && !b.now().Before(deadline) {
+			// Left for Get, Pop or Expire to evict.
+			return v, false
+		}
+	}
+	v = b.buffer[i]
+	if b.accessOrder {
+		b.moveToNewestLocked(i)
+		b.checkInvariants()
+	}
+	return v, true
+}
+
+// Move the item in cell i, with everything attached to it, to the
+// newest end. Must be called with the lock held.
+func (b *Buffer[T]) moveToNewestLocked(i uint) {
+	last := (b.pos - 1) & b.mask
+	if i == last {
+		return
+	}
+	// Park the item in the unused cell at pos, then close the gap
+	// it left, which shifts pos back to the parked item.
+	b.moveCell(b.pos, i)
+	for ; i != last; i = (i + 1) & b.mask {
+		b.moveCell(i, (i+1)&b.mask)
+	}
+	b.moveCell(last, b.pos)
+}
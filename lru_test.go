@@ -0,0 +1,51 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAccessOrder(t *testing.T) {
+	var evicted []string
+	c := NewBuffer[string](4, WithAccessOrder(), WithEvict(func(v string) {
+		evicted = append(evicted, v)
+	}))
+	c.PushKeyed("a", "a1")
+	c.NBPushMeta("x", "meta")
+	c.PushKeyed("b", "b1")
+
+	if v, ok := c.Lookup("a"); !ok || v != "a1" {
+		t.Error(v, ok)
+	}
+	if _, ok := c.Lookup("c"); ok {
+		t.Error("found c")
+	}
+	if got := c.Snapshot(); fmt.Sprint(got) != "[x b1 a1]" {
+		t.Error(got)
+	}
+	// Replacing moves too, and the least recently used goes.
+	c.PushKeyed("b", "b2")
+	c.PushKeyed("c", "c1")
+	if got := c.Snapshot(); fmt.Sprint(got) != "[a1 b2 c1]" || fmt.Sprint(evicted) != "[b1 x]" {
+		t.Error(got, evicted)
+	}
+	c.Lookup("a")
+	c.PushKeyed("d", "d1")
+	if v, ok := c.Lookup("b"); ok || fmt.Sprint(evicted) != "[b1 x b2]" {
+		t.Error(v, evicted)
+	}
+
+	// Without access order nothing moves.
+	d := NewBuffer[string](4)
+	d.PushKeyed("a", "a1")
+	d.PushKeyed("b", "b1")
+	if v, _ := d.Lookup("a"); v != "a1" || d.Snapshot()[0] != "a1" {
+		t.Error(d.Snapshot())
+	}
+
+	c.DrainAll()
+	d.DrainAll()
+	if c.verifyIsEmpty() != true || d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
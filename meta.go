@@ -0,0 +1,34 @@
+package circularbuffer
+
+// Nonblocking push of v tagged with arbitrary metadata, which is
+// stored alongside v and returned by GetMeta. Eviction works as in
+// NBPush. The metadata of evicted items is dropped.
+func (b *Buffer[T]) NBPushMeta(v T, meta interface{}) T {
+	evictv, _, _, err := b.push(v, meta, 0)
+	if err != nil {
+		return v
+	}
+	return evictv
+}
+
+// Get the oldest item along with its metadata (nil if it was pushed
+// without any) without blocking. ok is false if the buffer is empty.
+// Expired items are skipped and evicted, as in Get.
+func (b *Buffer[T]) GetMeta() (v T, meta interface{}, ok bool) {
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	if b.start != b.pos {
+		if b.meta != nil {
+			meta = b.meta[b.start]
+		}
+		v, ok = b.getLocked(), true
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	if ok {
+		hooks.taken(false, v)
+	}
+	return v, meta, ok
+}
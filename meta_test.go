@@ -0,0 +1,36 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMeta(t *testing.T) {
+	c := NewCircularBuffer(4) // up to 3 items in the buffer
+
+	c.NBPush(-1)
+	for i := 0; i < 4; i++ {
+		c.NBPushMeta(i, fmt.Sprint("trace-", i))
+	}
+
+	// -1 and 0 were evicted.
+	for i := 1; i < 4; i++ {
+		v, meta, ok := c.GetMeta()
+		if !ok || v != i || meta != fmt.Sprint("trace-", i) {
+			t.Error(v, meta, ok)
+		}
+	}
+
+	c.NBPush(4)
+	if v, meta, ok := c.GetMeta(); !ok || v != 4 || meta != nil {
+		t.Error(v, meta, ok)
+	}
+
+	if v, meta, ok := c.GetMeta(); ok {
+		t.Error(v, meta, ok)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,21 @@
+package circularbuffer
+
+import (
+	"expvar"
+)
+
+// Publish the buffer's Stats under name with expvar, so that they show
+// up in /debug/vars as {"Pushed": 10, "Evicted": 2, ...}. The values
+// are read on every request, the counters are cumulative, see Stats.
+// Like expvar.Publish, panics if name is already taken.
+func (b *Buffer[T]) PublishExpvar(name string) {
+	expvar.Publish(name, b.ExpvarVar())
+}
+
+// The buffer's Stats as an expvar.Var, for publishing in an expvar.Map
+// or under a name of one's choosing.
+func (b *Buffer[T]) ExpvarVar() expvar.Var {
+	return expvar.Func(func() interface{} {
+		return b.Stats()
+	})
+}
@@ -0,0 +1,45 @@
+package circularbuffer
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	c := NewBuffer[int](3)
+	// expvar names are global, keep them unique across -count runs.
+	name := fmt.Sprintf("%s_%d", t.Name(), time.Now().UnixNano())
+	c.PublishExpvar(name)
+	for i := 0; i < 4; i++ {
+		c.NBPush(i)
+	}
+	var s Stats
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &s); err != nil {
+		t.Error(err)
+	}
+	if s.Pushed != 4 || s.Evicted != 2 || s.Length != 2 || s.Cap != 2 {
+		t.Error(s)
+	}
+
+	// A blocked producer shows up.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.PushContext(ctx, 5) }()
+	for c.Stats().BlockedProducers != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if s := c.Stats(); s.BlockedProducers != 0 {
+		t.Error(s)
+	}
+	c.Get()
+	c.Get()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !circbufdebug
+
+package circularbuffer
+
+// Invariant checks are compiled in only with the circbufdebug build tag.
+func (b *Buffer[T]) checkInvariants() {}
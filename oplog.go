@@ -0,0 +1,88 @@
+package circularbuffer
+
+// Kind of a recorded operation.
+type OpKind int
+
+const (
+	OpPush OpKind = iota
+	OpGet
+	OpPop
+	OpPushFront
+	OpRequeue
+	OpSwapOldest
+	OpSwapNewest
+	OpRemove
+	OpPushKeyed
+)
+
+// Recorded operation. Value is the pushed item for OpPush and
+// OpPushFront, the removed item for OpGet and OpPop, the moved one for
+// OpRequeue and the new one for OpSwapOldest and OpSwapNewest. For
+// OpRemove, recorded for every item RemoveIf drops, it's the int index
+// of the item counting from the oldest. For OpPushKeyed it's a KeyedOp.
+type Op struct {
+	Kind  OpKind
+	Value interface{}
+}
+
+// Value of an OpPushKeyed, recorded for PushKeyed with a non-empty key.
+type KeyedOp struct {
+	Key   string
+	Value interface{}
+}
+
+// Start or stop recording operations to the in-memory op log. Meant
+// for debugging: the log grows without bounds while recording.
+func (b *Buffer[T]) SetRecording(on bool) {
+	b.lock.Lock()
+	b.recording = on
+	b.lock.Unlock()
+}
+
+// Copy of the recorded operations, oldest first.
+func (b *Buffer[T]) OpLog() []Op {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([]Op(nil), b.oplog...)
+}
+
+// Apply the recorded operations to the buffer. Pushes are replayed
+// with their values, gets and pops remove an item (if any) from the
+// respective end. Replaying a log into a fresh buffer created with the
+// same parameters reproduces the state of the recorded one.
+func (b *Buffer[T]) Replay(ops []Op) {
+	for _, op := range ops {
+		switch op.Kind {
+		case OpPush:
+			v, _ := op.Value.(T)
+			b.NBPush(v)
+		case OpPushFront:
+			v, _ := op.Value.(T)
+			b.PushFront(v)
+		case OpGet:
+			b.GetResult()
+		case OpPop:
+			b.PopResult()
+		case OpRequeue:
+			b.Requeue()
+		case OpSwapOldest:
+			v, _ := op.Value.(T)
+			b.SwapOldest(v)
+		case OpSwapNewest:
+			v, _ := op.Value.(T)
+			b.SwapNewest(v)
+		case OpPushKeyed:
+			kv, _ := op.Value.(KeyedOp)
+			v, _ := kv.Value.(T)
+			b.PushKeyed(kv.Key, v)
+		case OpRemove:
+			k, _ := op.Value.(int)
+			n := 0
+			b.RemoveIf(func(T) bool {
+				n++
+				return n-1 == k
+			})
+		}
+	}
+}
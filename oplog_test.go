@@ -0,0 +1,154 @@
+package circularbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	c := NewCircularBuffer(4)
+	c.NBPush(-1) // not recorded
+	c.SetRecording(true)
+
+	for i := 0; i < 6; i++ {
+		c.NBPush(i)
+	}
+	c.Get()
+	c.Pop()
+	c.NBPush(6)
+
+	ops := c.OpLog()
+	want := []Op{
+		{OpPush, 0}, {OpPush, 1}, {OpPush, 2}, {OpPush, 3},
+		{OpPush, 4}, {OpPush, 5}, {OpGet, 3}, {OpPop, 5},
+		{OpPush, 6},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Error(ops)
+	}
+
+	c.SetRecording(false)
+	c.NBPush(7)
+	if len(c.OpLog()) != len(want) {
+		t.Error(c.OpLog())
+	}
+	c.Pop()
+
+	r := NewCircularBuffer(4)
+	r.Replay(ops)
+
+	for i := 0; i < 2; i++ {
+		v, w := c.Get(), r.Get()
+		if v != w {
+			t.Error(v, w)
+		}
+	}
+
+	if c.verifyIsEmpty() != true || r.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestReplaySwap(t *testing.T) {
+	c := NewCircularBuffer(4)
+	c.SetRecording(true)
+
+	c.SwapOldest(0) // empty, pushed
+	c.NBPush(1)
+	c.NBPush(2)
+	c.SwapOldest(3)
+	c.SwapNewest(4)
+
+	ops := c.OpLog()
+	want := []Op{
+		{OpPush, 0}, {OpPush, 1}, {OpPush, 2},
+		{OpSwapOldest, 3}, {OpSwapNewest, 4},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Error(ops)
+	}
+
+	r := NewCircularBuffer(4)
+	r.Replay(ops)
+
+	for i := 0; i < 3; i++ {
+		v, w := c.Get(), r.Get()
+		if v != w {
+			t.Error(v, w)
+		}
+	}
+
+	if c.verifyIsEmpty() != true || r.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestReplayRemoveIf(t *testing.T) {
+	c := NewCircularBuffer(8)
+	c.SetRecording(true)
+
+	for i := 0; i < 6; i++ {
+		c.NBPush(i)
+	}
+	if n := c.RemoveIf(func(v interface{}) bool { return v.(int)%2 == 1 }); n != 3 {
+		t.Error(n)
+	}
+
+	ops := c.OpLog()
+	want := []Op{
+		{OpPush, 0}, {OpPush, 1}, {OpPush, 2}, {OpPush, 3},
+		{OpPush, 4}, {OpPush, 5}, {OpRemove, 1}, {OpRemove, 2},
+		{OpRemove, 3},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Error(ops)
+	}
+
+	r := NewCircularBuffer(8)
+	r.Replay(ops)
+
+	for i := 0; i < 3; i++ {
+		v, w := c.Get(), r.Get()
+		if v != w || v != i*2 {
+			t.Error(v, w)
+		}
+	}
+
+	if c.verifyIsEmpty() != true || r.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestReplayPushKeyed(t *testing.T) {
+	c := NewCircularBuffer(4)
+	c.SetRecording(true)
+
+	c.PushKeyed("a", 0)
+	c.PushKeyed("", 1)
+	c.PushKeyed("b", 2)
+	c.PushKeyed("a", 3)
+
+	ops := c.OpLog()
+	want := []Op{
+		{OpPushKeyed, KeyedOp{"a", 0}}, {OpPush, 1},
+		{OpPushKeyed, KeyedOp{"b", 2}}, {OpPushKeyed, KeyedOp{"a", 3}},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Error(ops)
+	}
+
+	r := NewCircularBuffer(4)
+	r.Replay(ops)
+	r.PushKeyed("b", 4)
+	c.PushKeyed("b", 4)
+
+	for _, w := range []int{3, 1, 4} {
+		if v, u := c.Get(), r.Get(); v != w || u != w {
+			t.Error(v, u)
+		}
+	}
+
+	if c.verifyIsEmpty() != true || r.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,40 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Configuration option for New, NewCircularBuffer and NewBuffer.
+//
+// Options are applied once, before the buffer is shared, which makes
+// them the race-free way to configure it. Available options:
+// WithOverflowPolicy, WithClosedPushMode, WithEvict, WithHooks (for
+// logging and metrics), WithClock, WithErrors, WithRetention, WithLess,
+// WithMaxCost, WithAccessOrder, WithCodec, WithGrowth and WithShrink.
+type Option func(c *config)
+
+// Settings of a buffer that don't depend on the item type.
+type config struct {
+	closedMode  ClosedPushMode
+	policy      OverflowPolicy
+	evict       interface{} // func(v T), checked by NewBuffer
+	hooks       interface{} // Hooks[T], checked by NewBuffer
+	noPanic     bool
+	clock       func() time.Time
+	retention   time.Duration
+	less        interface{} // func(a, b T) bool, checked by NewBuffer
+	maxCost     int64
+	costFn      interface{} // func(v T) int64, checked by NewBuffer
+	accessOrder bool
+	codec       interface{} // Codec[T], checked by NewBuffer
+	maxSize     uint
+	shrinkAfter time.Duration
+}
+
+// Set the clock used for throughput measurement and expiry, time.Now
+// by default. Meant for tests.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.clock = now
+	}
+}
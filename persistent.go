@@ -0,0 +1,236 @@
+package circularbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Layout of a PersistentRing file: a header, then slots fixed-size
+// slots, each a 4 byte length followed by up to slotSize bytes. All
+// integers are little-endian.
+const (
+	persistentMagic      = "CIRCBUF1"
+	persistentHeaderSize = 64
+	// Offsets of the header fields after the magic.
+	persistentSlotsOff    = 8  // uint32
+	persistentSlotSizeOff = 12 // uint32
+	persistentHeadOff     = 16 // uint64, sequence of the next record
+	persistentTailOff     = 24 // uint64, sequence of the oldest record
+)
+
+var (
+	// Returned by OpenPersistentRing when the file is not a ring of
+	// the requested geometry.
+	ErrBadRingFile = errors.New("circularbuffer: file is not a matching persistent ring")
+
+	// Returned by OpenPersistentRing where memory-mapped files are
+	// not supported.
+	errNoMmap = errors.New("circularbuffer: persistent ring not supported on this platform")
+)
+
+// Ring of byte records in a memory-mapped file, so that the most recent
+// records survive a restart: a black box recorder. Records are stored
+// in fixed-size slots, pushing to a full ring evicts the oldest, as in
+// RecordRing.
+//
+// Every write goes straight to the mapping, so a crash of the process
+// loses nothing the kernel has seen; call Sync to protect against a
+// crash of the machine. A slot is written before the header publishes
+// it, and the oldest record is dropped from the header before its slot
+// is reused, so a torn push loses at most the pushed and the evicted
+// record. A slot whose length prefix is corrupt, longer than the slot,
+// is skipped by TryGet and Records. Safe for concurrent use within a
+// process, not across processes.
+type PersistentRing struct {
+	lock     sync.Mutex
+	file     *os.File
+	data     []byte // the mapping, nil once closed
+	slots    uint64
+	slotSize int
+}
+
+// Open the ring in the file at path, creating it with the given number
+// of slots of slotSize bytes each if it doesn't exist. An existing file
+// must have the same geometry, or ErrBadRingFile is returned.
+func OpenPersistentRing(path string, slots, slotSize int) (*PersistentRing, error) {
+	if slots <= 0 || slotSize <= 0 {
+		return nil, ErrBadRingFile
+	}
+	size := persistentHeaderSize + slots*(recordHeaderSize+slotSize)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	fresh := st.Size() == 0
+	if fresh {
+		err = f.Truncate(int64(size))
+	} else if st.Size() != int64(size) {
+		err = ErrBadRingFile
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	data, err := mmapFile(f, size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := &PersistentRing{file: f, data: data, slots: uint64(slots), slotSize: slotSize}
+	le := binary.LittleEndian
+	if fresh {
+		copy(data, persistentMagic)
+		le.PutUint32(data[persistentSlotsOff:], uint32(slots))
+		le.PutUint32(data[persistentSlotSizeOff:], uint32(slotSize))
+	} else if string(data[:len(persistentMagic)]) != persistentMagic ||
+		le.Uint32(data[persistentSlotsOff:]) != uint32(slots) ||
+		le.Uint32(data[persistentSlotSizeOff:]) != uint32(slotSize) ||
+		r.head()-r.tail() > r.slots {
+		r.Close()
+		return nil, ErrBadRingFile
+	}
+	return r, nil
+}
+
+func (r *PersistentRing) head() uint64 {
+	return binary.LittleEndian.Uint64(r.data[persistentHeadOff:])
+}
+
+func (r *PersistentRing) tail() uint64 {
+	return binary.LittleEndian.Uint64(r.data[persistentTailOff:])
+}
+
+func (r *PersistentRing) setHead(seq uint64) {
+	binary.LittleEndian.PutUint64(r.data[persistentHeadOff:], seq)
+}
+
+func (r *PersistentRing) setTail(seq uint64) {
+	binary.LittleEndian.PutUint64(r.data[persistentTailOff:], seq)
+}
+
+// The slot of record seq, length prefix included.
+func (r *PersistentRing) slot(seq uint64) []byte {
+	n := recordHeaderSize + r.slotSize
+	off := persistentHeaderSize + int(seq%r.slots)*n
+	return r.data[off : off+n]
+}
+
+// Store a copy of rec, evicting the oldest record if the ring is full.
+// Returns ErrFull if rec is longer than the slot size, ErrClosed if the
+// ring is closed.
+func (r *PersistentRing) NBPush(rec []byte) (evicted bool, err error) {
+	if len(rec) > r.slotSize {
+		return false, ErrFull
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.data == nil {
+		return false, ErrClosed
+	}
+
+	head, tail := r.head(), r.tail()
+	if head-tail == r.slots {
+		r.setTail(tail + 1)
+		evicted = true
+	}
+	s := r.slot(head)
+	binary.LittleEndian.PutUint32(s, uint32(len(rec)))
+	copy(s[recordHeaderSize:], rec)
+	r.setHead(head + 1)
+	return evicted, nil
+}
+
+// Take the oldest record, appending it to dst. ok is false if the ring
+// is empty or closed.
+func (r *PersistentRing) TryGet(dst []byte) (rec []byte, ok bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.data == nil {
+		return dst, false
+	}
+	for tail := r.tail(); tail != r.head(); tail++ {
+		dst, ok = r.appendRecord(dst, tail)
+		r.setTail(tail + 1)
+		if ok {
+			return dst, true
+		}
+	}
+	return dst, false
+}
+
+// Copies of all the records, oldest first, without removing them.
+func (r *PersistentRing) Records() [][]byte {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.data == nil {
+		return nil
+	}
+	var recs [][]byte
+	for seq := r.tail(); seq != r.head(); seq++ {
+		if rec, ok := r.appendRecord(nil, seq); ok {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+// Append record seq to dst. ok is false if its length prefix doesn't
+// fit in the slot, e.g. the file got corrupted.
+func (r *PersistentRing) appendRecord(dst []byte, seq uint64) (rec []byte, ok bool) {
+	s := r.slot(seq)
+	n := binary.LittleEndian.Uint32(s)
+	if n > uint32(r.slotSize) {
+		return dst, false
+	}
+	return append(dst, s[recordHeaderSize:recordHeaderSize+int(n)]...), true
+}
+
+// Number of records in the ring.
+func (r *PersistentRing) Length() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.data == nil {
+		return 0
+	}
+	return int(r.head() - r.tail())
+}
+
+// Maximum number of records the ring can hold.
+func (r *PersistentRing) Cap() int {
+	return int(r.slots)
+}
+
+// Flush the mapping to disk, so that the records survive a crash of
+// the machine, not only of the process.
+func (r *PersistentRing) Sync() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.data == nil {
+		return ErrClosed
+	}
+	return msyncFile(r.data)
+}
+
+// Unmap and close the file. The records stay in the file, for the next
+// OpenPersistentRing. Closing a closed ring has no effect.
+func (r *PersistentRing) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.data == nil {
+		return nil
+	}
+	err := munmapFile(r.data)
+	r.data = nil
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || freebsd || openbsd || dragonfly)
+
+package circularbuffer
+
+import (
+	"os"
+)
+
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	return nil, errNoMmap
+}
+
+func munmapFile(data []byte) error {
+	return nil
+}
+
+func msyncFile(data []byte) error {
+	return errNoMmap
+}
@@ -0,0 +1,92 @@
+//go:build linux || darwin || freebsd || openbsd || dragonfly
+
+package circularbuffer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistentRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	r, err := OpenPersistentRing(path, 3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a", "bb", "ccc", "dddd"} {
+		evicted, err := r.NBPush([]byte(s))
+		if err != nil || evicted != (s == "dddd") {
+			t.Error(s, evicted, err)
+		}
+	}
+	if _, err := r.NBPush(make([]byte, 9)); err != ErrFull {
+		t.Error(err)
+	}
+	if rec, ok := r.TryGet(nil); !ok || string(rec) != "bb" {
+		t.Error(string(rec), ok)
+	}
+	if err := r.Sync(); err != nil {
+		t.Error(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err := r.NBPush(nil); err != ErrClosed {
+		t.Error(err)
+	}
+
+	// Geometry must match.
+	if _, err := OpenPersistentRing(path, 4, 8); err != ErrBadRingFile {
+		t.Error(err)
+	}
+
+	r, err = OpenPersistentRing(path, 3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	recs := r.Records()
+	if len(recs) != 2 || string(recs[0]) != "ccc" || string(recs[1]) != "dddd" {
+		t.Error(recs)
+	}
+	r.TryGet(nil)
+	r.TryGet(nil)
+	if _, ok := r.TryGet(nil); ok || r.Length() != 0 || r.Cap() != 3 {
+		t.Error(ok, r.Length())
+	}
+}
+
+func TestPersistentRingCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	r, err := OpenPersistentRing(path, 3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.NBPush([]byte("a"))
+	r.NBPush([]byte("bb"))
+	r.Close()
+
+	// A torn length prefix in the first slot.
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, persistentHeaderSize)
+	f.Close()
+
+	r, err = OpenPersistentRing(path, 3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if recs := r.Records(); len(recs) != 1 || string(recs[0]) != "bb" {
+		t.Error(recs)
+	}
+	if rec, ok := r.TryGet(nil); !ok || string(rec) != "bb" {
+		t.Error(string(rec), ok)
+	}
+	if _, ok := r.TryGet(nil); ok || r.Length() != 0 {
+		t.Error(ok, r.Length())
+	}
+}
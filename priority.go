@@ -0,0 +1,46 @@
+package circularbuffer
+
+// Evict by priority: when the buffer is full, the lowest priority item
+// goes instead of the oldest one, as ordered by less (a has a lower
+// priority than b). The pushed item itself is rejected if it has the
+// lowest priority of all. Ties go to the oldest item. Applies to the
+// DropOldest policy, and each overflow costs a scan of the buffer.
+//
+// less is called with the lock held and must not use the buffer. Its
+// item type must match the buffer's, use interface{} for
+// CircularBuffer.
+func WithLess[T any](less func(a, b T) bool) Option {
+	return func(c *config) {
+		c.less = less
+	}
+}
+
+// Index of the lowest priority item, or ok false if v is lower than
+// all of them. Must be called with the lock held, buffer must not be
+// empty.
+func (b *Buffer[T]) lowestLocked(v T) (idx uint, ok bool) {
+	idx = b.start
+	for i := (b.start + 1) & b.mask; i != b.pos; i = (i + 1) & b.mask {
+		if b.less(b.buffer[i], b.buffer[idx]) {
+			idx = i
+		}
+	}
+	if b.less(v, b.buffer[idx]) {
+		return 0, false
+	}
+	return idx, true
+}
+
+// Remove the item in cell i, keeping the order of the rest, and return
+// it. Doesn't count or signal anything. Must be called with the lock
+// held.
+func (b *Buffer[T]) removeAtLocked(i uint) T {
+	v := b.buffer[i]
+	b.clearCell(i)
+	// Shift the older items one cell up, over the gap.
+	for ; i != b.start; i = (i - 1) & b.mask {
+		b.moveCell(i, (i-1)&b.mask)
+	}
+	b.start = (b.start + 1) & b.mask
+	return v
+}
@@ -0,0 +1,48 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWithLess(t *testing.T) {
+	// Priority is the tens digit.
+	c := NewBuffer[int](5, WithLess(func(a, b int) bool {
+		return a/10 < b/10
+	}))
+	for _, v := range []int{30, 10, 20, 11} {
+		c.NBPush(v)
+	}
+	// The oldest of the lowest priority goes.
+	if v := c.NBPush(21); v != 10 {
+		t.Error(v)
+	}
+	if v := c.NBPush(22); v != 11 {
+		t.Error(v)
+	}
+	// Lower than everything, rejected.
+	if r := c.NBPushResult(5); r.OK || !r.Evicted || r.Value != 5 {
+		t.Error(r)
+	}
+	if got := c.Snapshot(); fmt.Sprint(got) != "[30 20 21 22]" {
+		t.Error(got)
+	}
+	if c.Stats().Evicted != 3 {
+		t.Error(c.Stats())
+	}
+	c.DrainAll()
+
+	// Metadata moves along.
+	d := NewBuffer[int](3, WithLess(func(a, b int) bool { return a < b }))
+	d.NBPushMeta(2, "two")
+	d.NBPushMeta(1, "one")
+	d.NBPushMeta(3, "three")
+	if v, meta, _ := d.GetMeta(); v != 2 || meta != "two" {
+		t.Error(v, meta)
+	}
+	d.Get()
+
+	if c.verifyIsEmpty() != true || d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,118 @@
+package circularbuffer
+
+import (
+	"context"
+	"sync"
+)
+
+// Blocking push. Waits for a free cell instead of evicting, so a slow
+// consumer applies backpressure to the producers. Pushing to a closed
+// buffer doesn't wait and behaves as NBPush does.
+func (b *Buffer[T]) Push(v T) {
+	b.PushContext(context.Background(), v)
+}
+
+// Blocking push like Push, giving up when ctx is done. Returns
+// ctx.Err() if the item was not pushed because of that, or ErrClosed
+// as NBPushErr does. Cost bounded buffers never wait, as in TryPush.
+func (b *Buffer[T]) PushContext(ctx context.Context, v T) error {
+	var key string
+	if b.dedupKey != nil {
+		key = b.dedupKey(v)
+	}
+	var evictbuf [1]T
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	if b.fullLocked() && !b.closed && b.costOf == nil {
+		if err := b.waitSpaceLocked(ctx); err != nil {
+			evict, hooks := b.Evict, b.hooks
+			b.lock.Unlock()
+			reportExpired(expired, evict, &hooks)
+			return err
+		}
+	}
+	evicted, stored, err := b.pushLocked(v, key, evictbuf[:0])
+	if err == errPushPanic {
+		b.lock.Unlock()
+		panic("circularbuffer: push to closed buffer")
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.pushed(v, stored, evicted)
+	// Only a closed buffer or the cost limit can evict here.
+	hooks.handOff(evict, evicted...)
+	return err
+}
+
+// Nonblocking push that never evicts. Returns ErrFull if the buffer is
+// full, leaving it to the caller to handle the overflow. Pushing to a
+// closed buffer behaves as NBPushErr does.
+func (b *Buffer[T]) TryPush(v T) error {
+	var key string
+	if b.dedupKey != nil {
+		key = b.dedupKey(v)
+	}
+	var evictbuf [1]T
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	if b.fullLocked() && !b.closed && b.costOf == nil {
+		evict, hooks := b.Evict, b.hooks
+		b.lock.Unlock()
+		reportExpired(expired, evict, &hooks)
+		return ErrFull
+	}
+	evicted, stored, err := b.pushLocked(v, key, evictbuf[:0])
+	if err == errPushPanic {
+		b.lock.Unlock()
+		panic("circularbuffer: push to closed buffer")
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.pushed(v, stored, evicted)
+	// Only a closed buffer or the cost limit can evict here.
+	hooks.handOff(evict, evicted...)
+	return err
+}
+
+// Wait until there is a free cell or the buffer is closed. Must be
+// called with the lock held.
+func (b *Buffer[T]) waitSpaceLocked(ctx context.Context) error {
+	if b.space == nil {
+		b.space = sync.NewCond(&b.lock)
+	}
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			b.lock.Lock()
+			b.space.Broadcast()
+			b.lock.Unlock()
+		})
+		defer stop()
+	}
+
+	for b.fullLocked() && !b.closed {
+		if err := ctx.Err(); err != nil {
+			// We may have taken a wakeup meant for another
+			// producer, pass it on.
+			b.signalSpaceLocked()
+			return err
+		}
+		b.blockedProducers++
+		b.space.Wait()
+		b.blockedProducers--
+	}
+	return nil
+}
+
+// Wake up a producer blocked in Push. Must be called with the lock
+// held, after freeing a cell.
+func (b *Buffer[T]) signalSpaceLocked() {
+	if b.space != nil {
+		b.space.Signal()
+	}
+}
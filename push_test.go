@@ -0,0 +1,159 @@
+package circularbuffer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPush(t *testing.T) {
+	c := NewBuffer[int](3) // up to 2 items in the buffer
+
+	c.Push(0)
+	c.Push(1)
+
+	var pushed int32
+	go func() {
+		c.Push(2)
+		atomic.StoreInt32(&pushed, 1)
+		c.Push(3)
+		atomic.StoreInt32(&pushed, 2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&pushed) != 0 {
+		t.Error("pushed to a full buffer")
+	}
+
+	// Nothing gets evicted, the producer waits for the consumer.
+	for i := 0; i < 4; i++ {
+		if v := c.Get(); v != i {
+			t.Error(v)
+		}
+	}
+	if atomic.LoadInt32(&pushed) != 2 {
+		t.Error(atomic.LoadInt32(&pushed))
+	}
+	if s := c.Stats(); s.Evicted != 0 {
+		t.Error(s)
+	}
+}
+
+func TestPushClosed(t *testing.T) {
+	c := NewBuffer[int](2, WithClosedPushMode(ClosedPushDrop))
+	c.Push(0)
+
+	done := make(chan struct{})
+	go func() {
+		c.Push(1)
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	// Closing wakes up the blocked producer, which drops the item.
+	c.Close()
+	<-done
+	if v := c.Get(); v != 0 {
+		t.Error(v)
+	}
+	if s := c.Stats(); s.Evicted != 1 {
+		t.Error(s)
+	}
+}
+
+func TestPushContext(t *testing.T) {
+	c := NewBuffer[int](2)
+
+	if err := c.PushContext(context.Background(), 0); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.PushContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Error(err)
+	}
+
+	// Cancelling one producer doesn't lose a wakeup for another.
+	ctx, cancel = context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		errs <- c.PushContext(ctx, 2)
+	}()
+	go func() {
+		errs <- c.PushContext(context.Background(), 3)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	if v := c.Get(); v != 0 {
+		t.Error(v)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && err != context.Canceled {
+			t.Error(err)
+		}
+	}
+	if v := c.Get(); v != 3 {
+		t.Error(v)
+	}
+
+	e := NewBuffer[int](2, WithClosedPushMode(ClosedPushError))
+	e.Close()
+	if err := e.PushContext(context.Background(), 0); err != ErrClosed {
+		t.Error(err)
+	}
+}
+
+func TestTryPush(t *testing.T) {
+	c := NewBuffer[int](3)
+
+	for i := 0; i < 2; i++ {
+		if err := c.TryPush(i); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := c.TryPush(2); err != ErrFull {
+		t.Error(err)
+	}
+	if s := c.Stats(); s.Pushed != 2 || s.Evicted != 0 || s.Length != 2 {
+		t.Error(s)
+	}
+
+	c.Get()
+	if err := c.TryPush(3); err != nil {
+		t.Error(err)
+	}
+	if items := c.Snapshot(); len(items) != 2 || items[0] != 1 || items[1] != 3 {
+		t.Error(items)
+	}
+}
+
+func TestPushContextCost(t *testing.T) {
+	c := NewBuffer[int](3, WithMaxCost(10, func(v int) int64 { return int64(v) }))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Past the slot count without blocking, evicting by cost only.
+	for i := 1; i <= 4; i++ {
+		if err := c.PushContext(ctx, i); err != nil {
+			t.Error(err)
+		}
+	}
+	if c.Length() != 4 {
+		t.Error(c.Length())
+	}
+	var evicted []int
+	c.Evict = func(v int) { evicted = append(evicted, v) }
+	if err := c.PushContext(ctx, 5); err != nil || len(evicted) != 3 || evicted[0] != 1 {
+		t.Error(err, evicted)
+	}
+	if s := c.Snapshot(); len(s) != 2 || s[0] != 4 || s[1] != 5 {
+		t.Error(s)
+	}
+	c.DrainAll()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,67 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Sliding-window rate limiter allowing at most max events per
+// duration. Timestamps of the allowed events are kept in a Buffer, so
+// the window is exact: an event is allowed if fewer than max events
+// happened within the last duration. Safe for concurrent use.
+type RateLimiter struct {
+	b   *Buffer[time.Time]
+	max int
+	per time.Duration
+}
+
+// Create RateLimiter allowing max events per duration. Of the options
+// only WithClock is meaningful, for tests.
+func NewRateLimiter(max int, per time.Duration, opts ...Option) *RateLimiter {
+	return &RateLimiter{
+		b:   NewBuffer[time.Time](uint(max)+1, opts...),
+		max: max,
+		per: per,
+	}
+}
+
+// Record an event now, if the limit allows it.
+func (r *RateLimiter) Allow() bool {
+	return r.AllowN(1)
+}
+
+// Record n events now, if the limit allows all of them. Otherwise
+// nothing is recorded.
+func (r *RateLimiter) AllowN(n int) bool {
+	b := r.b
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := r.expireLocked()
+	if int(b.used())+n > r.max {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		b.pushLocked(now, "", nil)
+	}
+	return true
+}
+
+// Number of events the limit allows now.
+func (r *RateLimiter) Remaining() int {
+	r.b.lock.Lock()
+	defer r.b.lock.Unlock()
+
+	r.expireLocked()
+	return r.max - int(r.b.used())
+}
+
+// Forget the events that left the window and return the current time.
+// Must be called with the lock held.
+func (r *RateLimiter) expireLocked() time.Time {
+	b := r.b
+	now := b.now()
+	for b.start != b.pos && now.Sub(b.buffer[b.start]) >= r.per {
+		b.getLocked()
+	}
+	return now
+}
@@ -0,0 +1,37 @@
+package circularbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	r := NewRateLimiter(3, time.Second, WithClock(clock.Now))
+
+	if !r.Allow() || !r.Allow() {
+		t.Error("denied")
+	}
+	clock.Advance(500 * time.Millisecond)
+	if !r.Allow() || r.Allow() || r.Remaining() != 0 {
+		t.Error("wrong limit")
+	}
+	// The first two events leave the window, the third doesn't.
+	clock.Advance(500 * time.Millisecond)
+	if r.Remaining() != 2 || r.AllowN(3) {
+		t.Error(r.Remaining())
+	}
+	if !r.AllowN(2) || r.Allow() {
+		t.Error("wrong limit")
+	}
+	if r.AllowN(4) {
+		t.Error("more than max allowed")
+	}
+	clock.Advance(time.Second)
+	if r.Remaining() != 3 {
+		t.Error(r.Remaining())
+	}
+	if r.b.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,68 @@
+package circularbuffer
+
+import (
+	"iter"
+)
+
+// Read-only view of a Buffer, see Freeze.
+type ReadOnlyBuffer[T any] struct {
+	b *Buffer[T]
+}
+
+// Read-only view of the buffer, to hand its contents to code that must
+// not push or consume. The view is live, it sees later changes made
+// through the buffer; use b.Clone().Freeze() for a fixed copy. Nothing
+// in the view gives access to the buffer itself.
+func (b *Buffer[T]) Freeze() ReadOnlyBuffer[T] {
+	return ReadOnlyBuffer[T]{b: b}
+}
+
+// Same as Buffer.Peek.
+func (r ReadOnlyBuffer[T]) Peek() (v T, ok bool) {
+	return r.b.Peek()
+}
+
+// Same as Buffer.PeekOldest.
+func (r ReadOnlyBuffer[T]) PeekOldest() (v T, ok bool) {
+	return r.b.PeekOldest()
+}
+
+// Same as Buffer.PeekNewest.
+func (r ReadOnlyBuffer[T]) PeekNewest() (v T, ok bool) {
+	return r.b.PeekNewest()
+}
+
+// Same as Buffer.Range.
+func (r ReadOnlyBuffer[T]) Range(fn func(i int, v T) bool) {
+	r.b.Range(fn)
+}
+
+// Same as Buffer.Find.
+func (r ReadOnlyBuffer[T]) Find(pred func(v T) bool) (v T, ok bool) {
+	return r.b.Find(pred)
+}
+
+// Same as Buffer.All.
+func (r ReadOnlyBuffer[T]) All() iter.Seq[T] {
+	return r.b.All()
+}
+
+// Same as Buffer.Snapshot.
+func (r ReadOnlyBuffer[T]) Snapshot() []T {
+	return r.b.Snapshot()
+}
+
+// Same as Buffer.Length.
+func (r ReadOnlyBuffer[T]) Length() int {
+	return r.b.Length()
+}
+
+// Same as Buffer.Empty.
+func (r ReadOnlyBuffer[T]) Empty() bool {
+	return r.b.Empty()
+}
+
+// Same as Buffer.Cap.
+func (r ReadOnlyBuffer[T]) Cap() int {
+	return r.b.Cap()
+}
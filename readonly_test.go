@@ -0,0 +1,43 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	c := NewBuffer[int](4)
+	c.NBPush(1)
+	c.NBPush(2)
+	r := c.Freeze()
+	if v, ok := r.Peek(); !ok || v != 1 || r.Length() != 2 || r.Cap() != 3 {
+		t.Error(v, ok)
+	}
+	if v, _ := r.PeekNewest(); v != 2 {
+		t.Error(v)
+	}
+	if v, ok := r.Find(func(v int) bool { return v > 1 }); !ok || v != 2 {
+		t.Error(v, ok)
+	}
+	sum := 0
+	for v := range r.All() {
+		sum += v
+	}
+	r.Range(func(i, v int) bool {
+		sum += v
+		return true
+	})
+	if sum != 6 {
+		t.Error(sum)
+	}
+
+	// The view is live.
+	c.Get()
+	if s := r.Snapshot(); len(s) != 1 || s[0] != 2 || r.Empty() {
+		t.Error(s)
+	}
+	frozen := c.Clone().Freeze()
+	c.Get()
+	if !r.Empty() || frozen.Length() != 1 {
+		t.Error(frozen.Snapshot())
+	}
+}
@@ -0,0 +1,130 @@
+package circularbuffer
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// Size of the length prefix of each record in a RecordRing.
+const recordHeaderSize = 4
+
+// Ring of variable-length byte records in a fixed arena, for bounded
+// message buffering without a heap allocation per message. Each record
+// is stored with a length prefix and may wrap around the end of the
+// arena. Pushing when there's not enough room evicts whole records,
+// oldest first. Safe for concurrent use.
+type RecordRing struct {
+	lock  sync.Mutex
+	arena []byte
+	start int // offset of the oldest record's header
+	used  int // bytes taken by records and their headers
+	count int // number of records
+}
+
+// Create RecordRing with an arena of size bytes. Each record takes 4
+// bytes on top of its length.
+func NewRecordRing(size uint) *RecordRing {
+	return &RecordRing{arena: make([]byte, size)}
+}
+
+// Store a copy of rec, evicting the oldest records if needed. Returns
+// the number of evicted records, or ErrFull if rec can't fit even in
+// an empty arena.
+func (r *RecordRing) NBPush(rec []byte) (evicted int, err error) {
+	need := recordHeaderSize + len(rec)
+	if need > len(r.arena) {
+		return 0, ErrFull
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for len(r.arena)-r.used < need {
+		r.discardLocked()
+		evicted++
+	}
+	var hdr [recordHeaderSize]byte
+	binary.LittleEndian.PutUint32(hdr[:], uint32(len(rec)))
+	end := r.start + r.used
+	r.copyIn(end, hdr[:])
+	r.copyIn(end+recordHeaderSize, rec)
+	r.used += need
+	r.count++
+	return evicted, nil
+}
+
+// Take the oldest record, appending it to dst, so that no allocation is
+// needed if dst has room. ok is false if the ring is empty.
+func (r *RecordRing) TryGet(dst []byte) (rec []byte, ok bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.count == 0 {
+		return dst, false
+	}
+	n := r.lengthLocked()
+	dst = r.copyOut(dst, r.start+recordHeaderSize, n)
+	r.discardLocked()
+	return dst, true
+}
+
+// Length of the oldest record, or -1 if the ring is empty. Use it to
+// size the dst for TryGet.
+func (r *RecordRing) PeekLength() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.count == 0 {
+		return -1
+	}
+	return r.lengthLocked()
+}
+
+// Number of records in the ring.
+func (r *RecordRing) Length() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.count
+}
+
+// Bytes of the arena in use, headers included.
+func (r *RecordRing) Used() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.used
+}
+
+// Size of the arena in bytes.
+func (r *RecordRing) Cap() int {
+	return len(r.arena)
+}
+
+func (r *RecordRing) lengthLocked() int {
+	var hdr [recordHeaderSize]byte
+	r.copyOut(hdr[:0], r.start, recordHeaderSize)
+	return int(binary.LittleEndian.Uint32(hdr[:]))
+}
+
+// Drop the oldest record.
+func (r *RecordRing) discardLocked() {
+	need := recordHeaderSize + r.lengthLocked()
+	r.start = (r.start + need) % len(r.arena)
+	r.used -= need
+	r.count--
+	if r.count == 0 {
+		r.start = 0
+	}
+}
+
+// Copy p to the arena at off, wrapping around the end.
+func (r *RecordRing) copyIn(off int, p []byte) {
+	off %= len(r.arena)
+	n := copy(r.arena[off:], p)
+	copy(r.arena, p[n:])
+}
+
+// Append n bytes of the arena at off to dst, wrapping around the end.
+func (r *RecordRing) copyOut(dst []byte, off, n int) []byte {
+	off %= len(r.arena)
+	if off+n <= len(r.arena) {
+		return append(dst, r.arena[off:off+n]...)
+	}
+	dst = append(dst, r.arena[off:]...)
+	return append(dst, r.arena[:n-(len(r.arena)-off)]...)
+}
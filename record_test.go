@@ -0,0 +1,65 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecordRing(t *testing.T) {
+	r := NewRecordRing(22)
+	if _, ok := r.TryGet(nil); ok || r.PeekLength() != -1 {
+		t.Error("not empty")
+	}
+	if _, err := r.NBPush(make([]byte, 19)); err != ErrFull {
+		t.Error(err)
+	}
+
+	// 4+3, 4+5 and 4+0 bytes.
+	r.NBPush([]byte("abc"))
+	r.NBPush([]byte("defgh"))
+	r.NBPush(nil)
+	if r.Length() != 3 || r.Used() != 20 {
+		t.Error(r.Length(), r.Used())
+	}
+	// Needs 10 bytes, the two oldest records make room.
+	if n, err := r.NBPush([]byte("ijklmn")); n != 2 || err != nil {
+		t.Error(n, err)
+	}
+	if r.PeekLength() != 0 {
+		t.Error(r.PeekLength())
+	}
+	buf := make([]byte, 0, 16)
+	if rec, ok := r.TryGet(buf); !ok || len(rec) != 0 {
+		t.Error(rec, ok)
+	}
+	// Wrapped around the end of the arena.
+	if rec, ok := r.TryGet(buf); !ok || string(rec) != "ijklmn" || &rec[0] != &buf[:1][0] {
+		t.Error(string(rec), ok)
+	}
+	if r.Length() != 0 || r.Used() != 0 {
+		t.Error(r.Length(), r.Used())
+	}
+}
+
+func TestRecordRingWrap(t *testing.T) {
+	r := NewRecordRing(23)
+	var want []string
+	for i := 0; i < 100; i++ {
+		rec := fmt.Sprint(i * i)
+		want = append(want, rec)
+		if _, err := r.NBPush([]byte(rec)); err != nil {
+			t.Error(err)
+		}
+		if i%3 == 0 {
+			if rec, ok := r.TryGet(nil); !ok || string(rec) != want[len(want)-r.Length()-1] {
+				t.Error(string(rec), ok)
+			}
+		}
+	}
+	// Whatever is left are the newest records, in order.
+	for n := r.Length(); n > 0; n-- {
+		if rec, _ := r.TryGet(nil); string(rec) != want[len(want)-n] {
+			t.Error(string(rec), want[len(want)-n])
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package circularbuffer
+
+// Move the oldest item to the newest end and return it, atomically,
+// e.g. to put a job that can't be handled yet at the back of the
+// queue. The item keeps its metadata, deadline and key, and doesn't
+// count as taken or pushed. ok is false if the buffer is empty.
+func (b *Buffer[T]) Requeue() (v T, ok bool) {
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	if b.start != b.pos {
+		v, ok = b.buffer[b.start], true
+		if b.recording {
+			b.oplog = append(b.oplog, Op{Kind: OpRequeue, Value: v})
+		}
+		// The cell at pos is always free.
+		b.moveCell(b.pos, b.start)
+		b.start = (b.start + 1) & b.mask
+		b.pos = (b.pos + 1) & b.mask
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	return v, ok
+}
@@ -0,0 +1,44 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestRequeue(t *testing.T) {
+	c := NewBuffer[int](4)
+	if v, ok := c.Requeue(); ok || v != 0 {
+		t.Error(v, ok)
+	}
+	c.PushKeyed("a", 1)
+	c.NBPush(2)
+	c.NBPush(3)
+	c.SetRecording(true)
+
+	// Works on a full buffer, nothing is evicted.
+	if v, ok := c.Requeue(); !ok || v != 1 {
+		t.Error(v, ok)
+	}
+	if s := c.Snapshot(); len(s) != 3 || s[0] != 2 || s[2] != 1 {
+		t.Error(s)
+	}
+	if s := c.Stats(); s.Pushed != 3 || s.Evicted != 0 || s.Gotten != 0 {
+		t.Error(s)
+	}
+	if ops := c.OpLog(); len(ops) != 1 || ops[0] != (Op{OpRequeue, 1}) {
+		t.Error(ops)
+	}
+
+	// The key moves with the item.
+	if v := c.PushKeyed("a", 10); v != 1 {
+		t.Error(v)
+	}
+	if s := c.Snapshot(); s[2] != 10 {
+		t.Error(s)
+	}
+	c.Get()
+	c.Get()
+	c.Get()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
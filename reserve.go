@@ -0,0 +1,206 @@
+package circularbuffer
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Identifies an item handed out by Reserve.
+type Token uint64
+
+type reservation[T any] struct {
+	v        T
+	deadline time.Time // zero if the lease never lapses
+}
+
+// Two-phase Get: remove the oldest item, blocking until one is
+// available or ctx is done, but keep it reserved until the consumer
+// calls Ack once it's processed, or Nack to have it redelivered. The
+// item counts as claimed meanwhile, see ClaimBatch, so WaitEmpty
+// waits for it.
+//
+// If the item is neither acked nor nacked within lease, e.g. because
+// the consumer crashed, the reservation lapses: the item is put back
+// at the oldest end, as Nack does, waking up a blocked consumer, and a
+// late Ack or Nack returns false. A timer does it on time, and the
+// next Reserve, Get, Pop or TryGet in case WithClock runs ahead of the
+// wall clock. A lease of zero or less never lapses.
+//
+// Returns ctx.Err() if ctx is done first, ErrClosed once the buffer is
+// closed and drained.
+func (b *Buffer[T]) Reserve(ctx context.Context, lease time.Duration) (v T, token Token, err error) {
+	var expired, evicted []T
+	b.lock.Lock()
+	for {
+		evicted = b.requeueLapsedLocked(evicted)
+		if err = b.waitItemsLocked(ctx, 1); err != nil {
+			evict, hooks := b.Evict, b.hooks
+			b.lock.Unlock()
+			reportExpired(expired, evict, &hooks)
+			hooks.evicted(evicted)
+			hooks.handOff(evict, evicted...)
+			return v, 0, err
+		}
+		var ok bool
+		if v, ok = b.takeLiveLocked(false, &expired); ok {
+			break
+		}
+	}
+	if b.reserved == nil {
+		b.reserved = make(map[Token]reservation[T])
+	}
+	b.lastToken++
+	token = b.lastToken
+	r := reservation[T]{v: v}
+	if lease > 0 {
+		r.deadline = b.now().Add(lease)
+		if b.leaseDue.IsZero() || r.deadline.Before(b.leaseDue) {
+			b.leaseDue = r.deadline
+			b.armLeaseLocked()
+		}
+	}
+	b.reserved[token] = r
+	b.claimed++
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.evicted(evicted)
+	hooks.handOff(evict, evicted...)
+	hooks.taken(false, v)
+	return v, token, nil
+}
+
+// Confirm that the reserved item was processed. Returns false if token
+// is not reserved, e.g. it was already acked or nacked.
+func (b *Buffer[T]) Ack(token Token) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.reserved[token]; !ok {
+		return false
+	}
+	delete(b.reserved, token)
+	b.claimed--
+	b.processed++
+	b.signalIdleLocked()
+	return true
+}
+
+// Give the reserved item back, for redelivery. It goes back to the
+// oldest end, so it's the next item to get. If the buffer filled up in
+// the meantime the item, being the oldest, is evicted instead. Nack
+// puts the item back even if the buffer was closed. Returns false if
+// token is not reserved.
+func (b *Buffer[T]) Nack(token Token) bool {
+	b.lock.Lock()
+	r, ok := b.reserved[token]
+	if !ok {
+		b.lock.Unlock()
+		return false
+	}
+	delete(b.reserved, token)
+	b.claimed--
+	evicted := b.putBackLocked(r.v, nil)
+	b.signalIdleLocked()
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	hooks.evicted(evicted)
+	hooks.handOff(evict, evicted...)
+	return true
+}
+
+// Put a reserved item back at the oldest end, or evict it if the
+// buffer is full, appending evicted items to evicted. Must be called
+// with the lock held.
+func (b *Buffer[T]) putBackLocked(v T, evicted []T) []T {
+	if b.fullLocked() {
+		b.noteEvictionLocked()
+		return append(evicted, v)
+	}
+	b.pushFrontLocked(v)
+	for b.costOf != nil && b.totalCost > b.maxCost && b.start != b.pos {
+		evicted = append(evicted, b.evictOldestLocked())
+	}
+	return evicted
+}
+
+// Put the items whose lease lapsed back, oldest reservation first,
+// appending evicted items to evicted. Must be called with the lock
+// held.
+func (b *Buffer[T]) requeueLapsedLocked(evicted []T) []T {
+	if b.leaseDue.IsZero() || b.now().Before(b.leaseDue) {
+		return evicted
+	}
+	now := b.now()
+	b.leaseDue = time.Time{}
+	var lapsed []Token
+	for token, r := range b.reserved {
+		switch {
+		case r.deadline.IsZero():
+		case now.Before(r.deadline):
+			if b.leaseDue.IsZero() || r.deadline.Before(b.leaseDue) {
+				b.leaseDue = r.deadline
+			}
+		default:
+			lapsed = append(lapsed, token)
+		}
+	}
+	// Each goes in front of the previous one, start with the newest.
+	slices.Sort(lapsed)
+	for i := len(lapsed) - 1; i >= 0; i-- {
+		r := b.reserved[lapsed[i]]
+		delete(b.reserved, lapsed[i])
+		b.claimed--
+		evicted = b.putBackLocked(r.v, evicted)
+	}
+	if len(lapsed) > 0 {
+		b.signalIdleLocked()
+	}
+	b.armLeaseLocked()
+	return evicted
+}
+
+// Make the lease timer fire at leaseDue, or stop it if there's none.
+// Must be called with the lock held.
+func (b *Buffer[T]) armLeaseLocked() {
+	if b.leaseDue.IsZero() {
+		if b.leaseTimer != nil {
+			b.leaseTimer.Stop()
+		}
+		return
+	}
+	d := b.leaseDue.Sub(b.now())
+	if b.leaseTimer == nil {
+		b.leaseTimer = time.AfterFunc(d, b.leaseLapsed)
+	} else {
+		b.leaseTimer.Reset(d)
+	}
+}
+
+// Lease timer callback: put the lapsed items back and rearm.
+func (b *Buffer[T]) leaseLapsed() {
+	b.lock.Lock()
+	evicted := b.requeueLapsedLocked(nil)
+	// Not due yet by WithClock, try again later.
+	b.armLeaseLocked()
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	hooks.evicted(evicted)
+	hooks.handOff(evict, evicted...)
+}
+
+// Store v before the oldest item. Must be called with the lock held,
+// buffer must not be full.
+func (b *Buffer[T]) pushFrontLocked(v T) {
+	b.growLocked()
+	b.start = (b.start - 1) & b.mask
+	b.setCell(b.start, v)
+	b.signalItemLocked()
+	b.lengthChangedLocked()
+}
@@ -0,0 +1,153 @@
+package circularbuffer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	c := NewBuffer[int](4)
+	var evicted []int
+	c.Evict = func(v int) { evicted = append(evicted, v) }
+	ctx := context.Background()
+	c.NBPush(1)
+	c.NBPush(2)
+
+	v, tok, err := c.Reserve(ctx, 0)
+	if v != 1 || err != nil || c.Length() != 1 {
+		t.Error(v, err)
+	}
+	// Redelivered first.
+	if !c.Nack(tok) || c.Nack(tok) || c.Ack(tok) {
+		t.Error(tok)
+	}
+	if v, tok, _ = c.Reserve(ctx, 0); v != 1 {
+		t.Error(v)
+	}
+	v, tok2, _ := c.Reserve(ctx, 0)
+	if v != 2 || tok2 == tok {
+		t.Error(v, tok2)
+	}
+	if !c.Ack(tok2) || c.Stats().Processed != 1 {
+		t.Error(c.Stats())
+	}
+
+	// Buffer filled up meanwhile, the nacked item is the oldest and
+	// gets evicted.
+	c.NBPush(3)
+	c.NBPush(4)
+	c.NBPush(5)
+	if !c.Nack(tok) || len(evicted) != 1 || evicted[0] != 1 {
+		t.Error(evicted)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	c.Get()
+	c.Get()
+	c.Get()
+	if _, _, err := c.Reserve(ctx, 0); err != context.Canceled {
+		t.Error(err)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestReserveWaitEmpty(t *testing.T) {
+	c := NewBuffer[int](4)
+	c.NBPush(1)
+	_, tok, _ := c.Reserve(context.Background(), 0)
+	done := make(chan struct{})
+	go func() {
+		c.WaitEmpty()
+		close(done)
+	}()
+	c.Nack(tok)
+	_, tok, _ = c.Reserve(context.Background(), 0)
+	c.Ack(tok)
+	<-done
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestReserveLease(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewBuffer[int](4, WithClock(clock.Now))
+	ctx := context.Background()
+	c.NBPush(1)
+	c.NBPush(2)
+	c.NBPush(3)
+
+	// Consumers that never come back.
+	_, tok1, _ := c.Reserve(ctx, time.Second)
+	_, tok2, _ := c.Reserve(ctx, time.Minute)
+	clock.Advance(2 * time.Second)
+
+	// Redelivered without a Nack, ahead of the rest.
+	v, tok, _ := c.Reserve(ctx, time.Second)
+	if v != 1 || c.Ack(tok1) || c.Nack(tok1) {
+		t.Error(v)
+	}
+	if !c.Ack(tok) {
+		t.Error(tok)
+	}
+	clock.Advance(time.Minute)
+	if v := c.Get(); v != 2 {
+		t.Error(v)
+	}
+	if c.Ack(tok2) {
+		t.Error(tok2)
+	}
+
+	// Once every lapsed item is back and taken, WaitEmpty returns.
+	_, tok, _ = c.Reserve(ctx, time.Second)
+	clock.Advance(time.Second)
+	if r := c.GetResult(); !r.OK || r.Value != 3 {
+		t.Error(r)
+	}
+	c.WaitEmpty()
+	if s := c.Stats(); s.Processed != 1 || s.Evicted != 0 {
+		t.Error(s)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestReserveLeaseBlockedConsumer(t *testing.T) {
+	c := NewBuffer[int](4)
+	c.NBPush(1)
+	// The first consumer dies with the item reserved.
+	if _, _, err := c.Reserve(context.Background(), 20*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+
+	// The second one is already waiting when the lease lapses.
+	got := make(chan int)
+	go func() {
+		v, tok, err := c.Reserve(context.Background(), time.Minute)
+		if err != nil {
+			t.Error(err)
+		}
+		c.Ack(tok)
+		got <- v
+	}()
+	for c.Stats().BlockedConsumers != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case v := <-got:
+		if v != 1 {
+			t.Error(v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("not redelivered")
+	}
+	c.WaitEmpty()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,21 @@
+package circularbuffer
+
+import (
+	"math/rand/v2"
+)
+
+// Push v to a full buffer under the Reservoir policy, appending the
+// item that doesn't make it to evicted. Must be called with the lock
+// held, after counting v in sampled.
+func (b *Buffer[T]) sampleLocked(v T, evicted []T) ([]T, bool, error) {
+	b.noteEvictionLocked()
+	j := rand.Uint64N(b.sampled)
+	if j >= uint64(b.used()) {
+		return append(evicted, v), false, nil
+	}
+	i := (b.start + uint(j)) & b.mask
+	evicted = append(evicted, b.buffer[i])
+	b.clearCell(i)
+	b.setCell(i, v)
+	return evicted, true, nil
+}
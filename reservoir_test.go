@@ -0,0 +1,41 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestReservoir(t *testing.T) {
+	const trials, n = 200, 10000
+	firstHalf := 0
+	for trial := 0; trial < trials; trial++ {
+		c := NewBuffer[int](101, WithOverflowPolicy(Reservoir))
+		for i := 0; i < n; i++ {
+			c.NBPush(i)
+		}
+		if c.Length() != 100 || c.Stats().Evicted != n-100 {
+			t.Fatal(c.Length(), c.Stats())
+		}
+		for _, v := range c.DrainAll() {
+			if v < n/2 {
+				firstHalf++
+			}
+		}
+		if c.verifyIsEmpty() != true {
+			t.Error("not empty")
+		}
+	}
+	// Old items are as likely to be kept as new ones.
+	if frac := float64(firstHalf) / (trials * 100); frac < 0.45 || frac > 0.55 {
+		t.Error(frac)
+	}
+
+	// The first items always make it in.
+	c := NewBuffer[int](4, WithOverflowPolicy(Reservoir))
+	c.NBPush(1)
+	c.NBPush(2)
+	c.NBPush(3)
+	if got := c.Snapshot(); len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Error(got)
+	}
+	c.DrainAll()
+}
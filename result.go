@@ -0,0 +1,50 @@
+package circularbuffer
+
+// Outcome of a single buffer operation.
+//
+// For pushes OK reports whether the item was stored, Evicted whether
+// an item was evicted and Value holds the evicted item. For Get and Pop
+// OK reports whether an item was available and Value holds it.
+type Result[T any] struct {
+	Value   T
+	OK      bool
+	Evicted bool
+}
+
+// Nonblocking push reporting the outcome as a Result. The Evict
+// callback, if set, is still called and Value is the zero value in
+// that case.
+func (b *Buffer[T]) NBPushResult(v T) Result[T] {
+	evictv, evicted, stored, _ := b.push(v, nil, 0)
+	return Result[T]{Value: evictv, OK: stored, Evicted: evicted}
+}
+
+// Get the oldest item without blocking. OK is false if the buffer is
+// empty.
+func (b *Buffer[T]) GetResult() Result[T] {
+	return b.takeResult(false)
+}
+
+// Pop the newest item without blocking. OK is false if the buffer is
+// empty.
+func (b *Buffer[T]) PopResult() Result[T] {
+	return b.takeResult(true)
+}
+
+func (b *Buffer[T]) takeResult(newest bool) Result[T] {
+	var expired []T
+	b.lock.Lock()
+	evicted := b.requeueLapsedLocked(nil)
+	v, ok := b.takeLiveLocked(newest, &expired)
+	evict, hooks := b.Evict, b.hooks
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	hooks.evicted(evicted)
+	hooks.handOff(evict, evicted...)
+	if !ok {
+		return Result[T]{}
+	}
+	hooks.taken(newest, v)
+	return Result[T]{Value: v, OK: true}
+}
@@ -0,0 +1,61 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	c := NewCircularBuffer(3) // up to 2 items in the buffer
+
+	if r := c.GetResult(); r.OK || r.Value != nil || r.Evicted {
+		t.Error(r)
+	}
+	if r := c.PopResult(); r.OK || r.Value != nil || r.Evicted {
+		t.Error(r)
+	}
+
+	for i := 0; i < 2; i++ {
+		if r := c.NBPushResult(i); !r.OK || r.Value != nil || r.Evicted {
+			t.Error(r)
+		}
+	}
+	if r := c.NBPushResult(2); !r.OK || r.Value != 0 || !r.Evicted {
+		t.Error(r)
+	}
+
+	c.SetPolicy(DropNewest)
+	if r := c.NBPushResult(3); r.OK || r.Value != 3 || !r.Evicted {
+		t.Error(r)
+	}
+
+	if r := c.GetResult(); !r.OK || r.Value != 1 || r.Evicted {
+		t.Error(r)
+	}
+	if r := c.PopResult(); !r.OK || r.Value != 2 || r.Evicted {
+		t.Error(r)
+	}
+	if r := c.GetResult(); r.OK {
+		t.Error(r)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestResultEvictCallback(t *testing.T) {
+	c := NewCircularBuffer(2)
+
+	var evicted []interface{}
+	c.Evict = func(v interface{}) {
+		evicted = append(evicted, v)
+	}
+
+	c.NBPushResult(0)
+	if r := c.NBPushResult(1); !r.OK || r.Value != nil || !r.Evicted {
+		t.Error(r)
+	}
+	if len(evicted) != 1 || evicted[0] != 0 {
+		t.Error(evicted)
+	}
+}
@@ -0,0 +1,131 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Keep items for at most d after they were pushed, regardless of how
+// many there are. Expired items are evicted lazily, before every push
+// and when Get or Pop reach them, or eagerly by Expire. They go to the
+// Evict callback like any other evicted item, and to the OnExpire hook.
+// The age is measured with the buffer's clock, see WithClock.
+func WithRetention(d time.Duration) Option {
+	return func(c *config) {
+		c.retention = d
+	}
+}
+
+// Nonblocking push of v that expires after ttl. Expired items are
+// never returned by Get, Pop and their variants, GetN or DrainAll:
+// they are skipped and evicted, see WithRetention. Other than that
+// works as NBPush. A ttl of zero or less means the item doesn't get
+// its own deadline.
+func (b *Buffer[T]) PushTTL(v T, ttl time.Duration) T {
+	evictv, _, _, err := b.push(v, nil, ttl)
+	if err != nil {
+		return v
+	}
+	return evictv
+}
+
+// Evict the oldest items past their deadline now, and return how many
+// there were. Call it periodically if expired items must not linger
+// while nothing is pushed or taken. Items with a later deadline behind
+// the oldest one, possible with PushTTL, stay until Get or Pop reach
+// them.
+func (b *Buffer[T]) Expire() int {
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	return len(expired)
+}
+
+// Evict the oldest items while they are past their deadline, appending
+// them to evicted. Must be called with the lock held.
+func (b *Buffer[T]) expireLocked(evicted []T) []T {
+	if b.expires == nil {
+		return evicted
+	}
+	now := b.now()
+	for b.start != b.pos {
+		deadline := b.expires[b.start]
+		if deadline.IsZero() || now.Before(deadline) {
+			break
+		}
+		evicted = append(evicted, b.evictOldestLocked())
+	}
+	return evicted
+}
+
+// Remove the oldest or the newest item, evicting expired ones on the
+// way and appending them to expired. ok is false if no live item is
+// left. Must be called with the lock held.
+func (b *Buffer[T]) takeLiveLocked(newest bool, expired *[]T) (v T, ok bool) {
+	for b.start != b.pos {
+		if b.expires != nil {
+			i := b.start
+			if newest {
+				i = (b.pos - 1) & b.mask
+			}
+			if deadline := b.expires[i]; !deadline.IsZero() && !b.now().Before(deadline) {
+				if newest {
+					*expired = append(*expired, b.evictNewestLocked())
+				} else {
+					*expired = append(*expired, b.evictOldestLocked())
+				}
+				continue
+			}
+		}
+		if newest {
+			return b.popLocked(), true
+		}
+		return b.getLocked(), true
+	}
+	return v, false
+}
+
+// Remove the newest item as evicted and return it. Must be called with
+// the lock held, buffer must not be empty.
+func (b *Buffer[T]) evictNewestLocked() T {
+	b.pos = (b.pos - 1) & b.mask
+	v := b.buffer[b.pos]
+	b.clearCell(b.pos)
+	b.noteEvictionLocked()
+	b.signalIdleLocked()
+	b.signalSpaceLocked()
+	b.lengthChangedLocked()
+	return v
+}
+
+// Set the retention deadline of the item in cell i, pushed just now.
+// Must be called with the lock held.
+func (b *Buffer[T]) stampLocked(i uint) {
+	if b.retention > 0 {
+		b.setDeadlineLocked(i, b.retention)
+	}
+}
+
+// Expire the item in cell i after ttl. Must be called with the lock
+// held.
+func (b *Buffer[T]) setDeadlineLocked(i uint, ttl time.Duration) {
+	if b.expires == nil {
+		b.expires = make([]time.Time, len(b.buffer))
+	}
+	b.expires[i] = b.now().Add(ttl)
+}
+
+// Pass expired items to the hooks and the Evict callback, then to
+// OnRecycle. Must be called without the lock.
+func reportExpired[T any](items []T, evict func(v T), hooks *Hooks[T]) {
+	for _, v := range items {
+		if hooks.OnExpire != nil {
+			hooks.OnExpire(v)
+		}
+		hooks.evict(v)
+		hooks.handOff(evict, v)
+	}
+}
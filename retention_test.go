@@ -0,0 +1,166 @@
+package circularbuffer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetention(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	var evicted []int
+	c := NewBuffer[int](100, WithClock(clock.Now), WithRetention(time.Minute),
+		WithEvict(func(v int) {
+			evicted = append(evicted, v)
+		}))
+
+	c.NBPush(1)
+	c.NBPush(2)
+	clock.Advance(30 * time.Second)
+	c.NBPush(3)
+	clock.Advance(30 * time.Second)
+	// 1 and 2 are a minute old, expired before the push.
+	c.NBPush(4)
+	if fmt.Sprint(evicted) != "[1 2]" || c.Length() != 2 {
+		t.Error(evicted, c.Length())
+	}
+
+	clock.Advance(time.Minute)
+	if n := c.Expire(); n != 2 || fmt.Sprint(evicted) != "[1 2 3 4]" {
+		t.Error(n, evicted)
+	}
+	if c.Expire() != 0 {
+		t.Error("expired twice")
+	}
+
+	// Deadlines move with the items.
+	for i := 5; i < 8; i++ {
+		c.NBPush(i)
+		clock.Advance(10 * time.Second)
+	}
+	c.Resize(10)
+	cl := c.Clone()
+	clock.Advance(45 * time.Second)
+	if n := cl.Expire(); n != 2 {
+		t.Error(n)
+	}
+	if n := c.Expire(); n != 2 || c.Get() != 7 {
+		t.Error(n)
+	}
+	if c.Stats().Evicted != 6 {
+		t.Error(c.Stats())
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestPushTTL(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	var evicted, expired []int
+	c := NewBuffer[int](100, WithClock(clock.Now),
+		WithEvict(func(v int) {
+			evicted = append(evicted, v)
+		}),
+		WithHooks(Hooks[int]{OnExpire: func(v int) {
+			expired = append(expired, v)
+		}}))
+
+	c.PushTTL(1, time.Second)
+	c.NBPush(2)
+	c.PushTTL(3, time.Minute)
+	c.PushTTL(4, time.Second)
+	c.PushTTL(5, 0)
+	clock.Advance(time.Second)
+
+	// Expired items are skipped from both ends, wherever they are.
+	if v := c.Get(); v != 2 {
+		t.Error(v)
+	}
+	if v := c.Pop(); v != 5 {
+		t.Error(v)
+	}
+	if v := c.Pop(); v != 3 {
+		t.Error(v)
+	}
+	if fmt.Sprint(evicted) != "[1 4]" || fmt.Sprint(expired) != "[1 4]" {
+		t.Error(evicted, expired)
+	}
+
+	c.PushTTL(6, time.Second)
+	c.PushTTL(7, time.Minute)
+	c.PushTTL(8, time.Second)
+	clock.Advance(time.Second)
+	if got := c.DrainAll(); fmt.Sprint(got) != "[7]" {
+		t.Error(got)
+	}
+	c.PushTTL(9, time.Second)
+	clock.Advance(time.Second)
+	if r := c.GetResult(); r.OK {
+		t.Error(r)
+	}
+	if fmt.Sprint(expired) != "[1 4 6 8 9]" || c.Stats().Evicted != 5 {
+		t.Error(expired, c.Stats())
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestExpiredSkippedEverywhere(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	var expired []int
+	c := NewBuffer[int](100, WithClock(clock.Now),
+		WithHooks(Hooks[int]{OnExpire: func(v int) {
+			expired = append(expired, v)
+		}}))
+
+	c.PushTTL(1, time.Second)
+	c.NBPush(2)
+	c.PushTTL(3, time.Second)
+	clock.Advance(2 * time.Second)
+	items, release := c.ClaimBatch(context.Background(), 10)
+	if fmt.Sprint(items) != "[2]" || fmt.Sprint(expired) != "[1 3]" {
+		t.Error(items, expired)
+	}
+	release()
+
+	c.PushTTL(4, time.Second)
+	c.NBPushMeta(5, "m")
+	clock.Advance(2 * time.Second)
+	if v, meta, ok := c.GetMeta(); v != 5 || meta != "m" || !ok {
+		t.Error(v, meta, ok)
+	}
+	c.PushTTL(6, time.Second)
+	clock.Advance(2 * time.Second)
+	if v, _, ok := c.GetMeta(); ok {
+		t.Error(v)
+	}
+
+	c.PushTTL(7, time.Second)
+	c.NBPush(8)
+	c.PushTTL(9, time.Second)
+	clock.Advance(2 * time.Second)
+	c.IfLengthAtLeast(3, func(l *LockedBuffer[int]) {
+		if v, ok := l.Pop(); v != 8 || !ok {
+			t.Error(v, ok)
+		}
+		if v, ok := l.Get(); ok {
+			t.Error(v)
+		}
+		// Reported only after the lock is released.
+		if len(expired) != 4 {
+			t.Error(expired)
+		}
+	})
+	if fmt.Sprint(expired) != "[1 3 4 6 9 7]" || c.Stats().Evicted != 6 {
+		t.Error(expired, c.Stats())
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,196 @@
+package circularbuffer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// How a SequencedRing producer or consumer waits for the other side.
+type WaitStrategy int
+
+const (
+	// Spin on the sequence. Lowest latency, burns a core per waiter.
+	WaitBusySpin WaitStrategy = iota
+	// Spin, yielding the processor between checks.
+	WaitYield
+	// Sleep on a condition variable until woken up. Cheapest on CPU,
+	// but every publish checks for sleepers.
+	WaitBlock
+)
+
+// Sequenced ring in the style of the LMAX Disruptor. Every published
+// item gets a monotonically increasing sequence number and each
+// consumer reads all of them through its own Cursor, so the ring fans
+// out every item to every consumer instead of handing each item to one
+// of them.
+//
+// Unlike Buffer nothing is evicted: a producer waits until the slowest
+// cursor has moved past the slot it's about to reuse. Publish is safe
+// for concurrent producers, each Cursor must be used by a single
+// goroutine. Slots are not cleared after reading, a ring keeps up to
+// its size of items reachable.
+type SequencedRing[T any] struct {
+	cells   []sequencedCell[T]
+	mask    int64
+	wait    WaitStrategy
+	cursors []*Cursor[T]
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	waiters atomic.Int32 // goroutines asleep in WaitBlock
+
+	_       cacheLinePad
+	claimed atomic.Int64 // next sequence to hand to a producer
+	gating  atomic.Int64 // cached minimum of the cursors, may lag behind
+	_       cacheLinePad
+}
+
+type sequencedCell[T any] struct {
+	published atomic.Int64 // sequence of the item in v
+	v         T
+}
+
+// Consumer's position in a SequencedRing.
+type Cursor[T any] struct {
+	r    *SequencedRing[T]
+	_    cacheLinePad
+	next atomic.Int64 // next sequence to read
+	_    cacheLinePad
+}
+
+// Create SequencedRing holding up to size items, read by the given
+// number of cursors. The size is rounded up to a power of two.
+func NewSequencedRing[T any](size uint, consumers int, wait WaitStrategy) *SequencedRing[T] {
+	n := int64(ringSize(size))
+	r := &SequencedRing[T]{
+		cells:   make([]sequencedCell[T], n),
+		mask:    n - 1,
+		wait:    wait,
+		cursors: make([]*Cursor[T], consumers),
+	}
+	r.cond = sync.NewCond(&r.mu)
+	for i := range r.cells {
+		// Nothing published yet, as if from the lap before.
+		r.cells[i].published.Store(int64(i) - n)
+	}
+	for i := range r.cursors {
+		r.cursors[i] = &Cursor[T]{r: r}
+	}
+	return r
+}
+
+// The i-th consumer's cursor.
+func (r *SequencedRing[T]) Cursor(i int) *Cursor[T] {
+	return r.cursors[i]
+}
+
+// Publish v and return its sequence number, waiting if the slowest
+// cursor hasn't read the item in the slot yet.
+func (r *SequencedRing[T]) Publish(v T) int64 {
+	seq := r.claimed.Add(1) - 1
+	wrap := seq - int64(len(r.cells))
+	if wrap >= r.gating.Load() {
+		r.waitFor(func() bool {
+			min := r.minCursor()
+			r.gating.Store(min)
+			return wrap < min
+		})
+	}
+	c := &r.cells[seq&r.mask]
+	c.v = v
+	// Publish the item to the cursors.
+	c.published.Store(seq)
+	r.wakeup()
+	return seq
+}
+
+// Highest sequence number claimed by a producer, -1 if none. Items up
+// to it may still be in the middle of being published.
+func (r *SequencedRing[T]) Sequence() int64 {
+	return r.claimed.Load() - 1
+}
+
+// Maximum number of items the ring can hold.
+func (r *SequencedRing[T]) Cap() int {
+	return len(r.cells)
+}
+
+func (r *SequencedRing[T]) minCursor() int64 {
+	min := r.claimed.Load()
+	for _, c := range r.cursors {
+		if next := c.next.Load(); next < min {
+			min = next
+		}
+	}
+	return min
+}
+
+// Wait according to the strategy until ready returns true.
+func (r *SequencedRing[T]) waitFor(ready func() bool) {
+	for !ready() {
+		switch r.wait {
+		case WaitYield:
+			runtime.Gosched()
+		case WaitBlock:
+			r.mu.Lock()
+			// Register before the last check, so that a wakeup
+			// after it can't be missed.
+			r.waiters.Add(1)
+			for !ready() {
+				r.cond.Wait()
+			}
+			r.waiters.Add(-1)
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *SequencedRing[T]) wakeup() {
+	if r.waiters.Load() > 0 {
+		r.mu.Lock()
+		r.cond.Broadcast()
+		r.mu.Unlock()
+	}
+}
+
+// Read the next item, waiting for it to be published.
+func (c *Cursor[T]) Next() (v T, seq int64) {
+	seq = c.next.Load()
+	cell := &c.r.cells[seq&c.r.mask]
+	if cell.published.Load() != seq {
+		c.r.waitFor(func() bool {
+			return cell.published.Load() == seq
+		})
+	}
+	return c.advance(cell, seq), seq
+}
+
+// Read the next item without waiting. ok is false if it's not
+// published yet.
+func (c *Cursor[T]) TryNext() (v T, seq int64, ok bool) {
+	seq = c.next.Load()
+	cell := &c.r.cells[seq&c.r.mask]
+	if cell.published.Load() != seq {
+		return v, seq, false
+	}
+	return c.advance(cell, seq), seq, true
+}
+
+func (c *Cursor[T]) advance(cell *sequencedCell[T], seq int64) T {
+	v := cell.v
+	// Done with the slot, let the producers reuse it.
+	c.next.Store(seq + 1)
+	c.r.wakeup()
+	return v
+}
+
+// Sequence number of the next item this cursor will read.
+func (c *Cursor[T]) Position() int64 {
+	return c.next.Load()
+}
+
+// Number of claimed items this cursor hasn't read yet.
+func (c *Cursor[T]) Lag() int {
+	return int(c.r.claimed.Load() - c.next.Load())
+}
@@ -0,0 +1,123 @@
+package circularbuffer
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSequencedRing(t *testing.T) {
+	r := NewSequencedRing[int](3, 2, WaitBusySpin) // rounded up to 4
+	if r.Cap() != 4 || r.Sequence() != -1 {
+		t.Error(r.Cap(), r.Sequence())
+	}
+	a, b := r.Cursor(0), r.Cursor(1)
+	if _, _, ok := a.TryNext(); ok {
+		t.Error("not empty")
+	}
+
+	for i := 0; i < 4; i++ {
+		if seq := r.Publish(10 + i); seq != int64(i) {
+			t.Error(seq)
+		}
+	}
+	// Every cursor sees every item.
+	for i := 0; i < 4; i++ {
+		if v, seq := a.Next(); v != 10+i || seq != int64(i) {
+			t.Error(v, seq)
+		}
+	}
+	if a.Lag() != 0 || b.Lag() != 4 || b.Position() != 0 {
+		t.Error(a.Lag(), b.Lag(), b.Position())
+	}
+	if v, seq, ok := b.TryNext(); !ok || v != 10 || seq != 0 {
+		t.Error(v, seq, ok)
+	}
+
+	// b freed one slot, so one more fits.
+	r.Publish(14)
+	if v, seq, ok := a.TryNext(); !ok || v != 14 || seq != 4 {
+		t.Error(v, seq, ok)
+	}
+	if r.Sequence() != 4 || b.Lag() != 4 {
+		t.Error(r.Sequence(), b.Lag())
+	}
+}
+
+func TestSequencedRingFanOut(t *testing.T) {
+	const producers, consumers, n = 4, 3, 2000
+	waits := []WaitStrategy{WaitYield, WaitBlock}
+	if runtime.GOMAXPROCS(0) >= producers+consumers {
+		// Spinning goroutines only make progress with a core each.
+		waits = append(waits, WaitBusySpin)
+	}
+	for _, wait := range waits {
+		r := NewSequencedRing[int](16, consumers, wait)
+
+		var wg sync.WaitGroup
+		sums := make([]int, consumers)
+		for i := 0; i < consumers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c := r.Cursor(i)
+				prev := int64(-1)
+				for j := 0; j < producers*n; j++ {
+					v, seq := c.Next()
+					if seq != prev+1 {
+						t.Error("out of order", seq, prev)
+					}
+					prev = seq
+					sums[i] += v
+				}
+			}(i)
+		}
+
+		var pwg sync.WaitGroup
+		for p := 0; p < producers; p++ {
+			pwg.Add(1)
+			go func() {
+				defer pwg.Done()
+				for j := 1; j <= n; j++ {
+					r.Publish(j)
+				}
+			}()
+		}
+		pwg.Wait()
+		wg.Wait()
+
+		for i, sum := range sums {
+			if sum != producers*n*(n+1)/2 {
+				t.Error(wait, i, sum)
+			}
+		}
+	}
+}
+
+func BenchmarkSequencedRing(b *testing.B) {
+	for _, wait := range []WaitStrategy{WaitYield, WaitBlock} {
+		for _, c := range []int{1, 4} {
+			name := fmt.Sprintf("wait%d-c%d", wait, c)
+			b.Run(name, func(b *testing.B) {
+				r := NewSequencedRing[int](1024, c, wait)
+				var wg sync.WaitGroup
+				for i := 0; i < c; i++ {
+					wg.Add(1)
+					go func(cur *Cursor[int]) {
+						defer wg.Done()
+						for j := 0; j < b.N; j++ {
+							cur.Next()
+						}
+					}(r.Cursor(i))
+				}
+				b.ResetTimer()
+				for j := 0; j < b.N; j++ {
+					r.Publish(j)
+				}
+				wg.Wait()
+				b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+			})
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package circularbuffer
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// Set of CircularBuffers spreading pushes by key, or round-robin, to
+// reduce lock contention between many producers. Items with the same
+// key land in the same shard, so their relative order is kept. There
+// is no ordering between different shards, so consumers see the items
+// in approximate FIFO order.
+type ShardedBuffer struct {
+	shards []*CircularBuffer
+	keyOf  func(v interface{}) string
+	next   uint32        // shard to start the next Get from
+	pushTo uint32        // shard for the next round-robin push
+	notify chan struct{} // wakes up a consumer blocked in Get
+}
+
+// Create ShardedBuffer of n shards, each a CircularBuffer of the given
+// size. If keyOf is nil pushes are spread round-robin, which balances
+// the shards but keeps no order even between items of one producer.
+func NewShardedBuffer(n int, size uint, keyOf func(interface{}) string) *ShardedBuffer {
+	s := &ShardedBuffer{
+		shards: make([]*CircularBuffer, n),
+		keyOf:  keyOf,
+		notify: make(chan struct{}, 1),
+	}
+	for i := range s.shards {
+		s.shards[i] = NewCircularBuffer(size)
+	}
+	return s
+}
+
+// Nonblocking push to the shard selected by the key of v, or the next
+// one with round-robin. Returns the
+// evicted item (if any), as CircularBuffer.NBPush does.
+func (s *ShardedBuffer) NBPush(v interface{}) interface{} {
+	var i uint32
+	if s.keyOf != nil {
+		h := fnv.New32a()
+		h.Write([]byte(s.keyOf(v)))
+		i = h.Sum32()
+	} else {
+		i = atomic.AddUint32(&s.pushTo, 1)
+	}
+	evictv := s.shards[i%uint32(len(s.shards))].NBPush(v)
+	s.wakeup()
+	return evictv
+}
+
+// Get the oldest item from one of the shards, blocking. Shards are
+// visited round-robin, so a busy shard can't starve the others.
+func (s *ShardedBuffer) Get() interface{} {
+	for {
+		start := atomic.AddUint32(&s.next, 1)
+		for i := range s.shards {
+			shard := s.shards[(int(start)+i)%len(s.shards)]
+			if r := shard.GetResult(); r.OK {
+				if s.Length() > 0 {
+					// More items left, pass the wakeup
+					// on to the next blocked consumer.
+					s.wakeup()
+				}
+				return r.Value
+			}
+		}
+		<-s.notify
+	}
+}
+
+func (s *ShardedBuffer) wakeup() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Total length of all the shards.
+func (s *ShardedBuffer) Length() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Length()
+	}
+	return n
+}
+
+// Counters summed over all the shards. PeakLength is the highest of
+// the shards' and LastEviction the latest.
+func (s *ShardedBuffer) Stats() Stats {
+	var total Stats
+	for _, shard := range s.shards {
+		st := shard.Stats()
+		total.Pushed += st.Pushed
+		total.Evicted += st.Evicted
+		total.Gotten += st.Gotten
+		total.Popped += st.Popped
+		total.Removed += st.Removed
+		total.Processed += st.Processed
+		total.EvictedChanDropped += st.EvictedChanDropped
+		total.Length += st.Length
+		total.Cap += st.Cap
+		total.PeakLength = max(total.PeakLength, st.PeakLength)
+		if st.LastEviction.After(total.LastEviction) {
+			total.LastEviction = st.LastEviction
+		}
+		total.BlockedConsumers += st.BlockedConsumers
+		total.BlockedProducers += st.BlockedProducers
+	}
+	return total
+}
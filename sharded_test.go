@@ -0,0 +1,136 @@
+package circularbuffer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedBuffer(t *testing.T) {
+	s := NewShardedBuffer(4, 1000, func(v interface{}) string {
+		return fmt.Sprint(v.(int) % 7)
+	})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if v := s.NBPush(g*100 + i); v != nil {
+					t.Error(v)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if s.Length() != 800 {
+		t.Error(s.Length())
+	}
+
+	seen := make(map[int]bool)
+	var mu sync.Mutex
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				v := s.Get().(int)
+				mu.Lock()
+				if seen[v] {
+					t.Error(v)
+				}
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 800 || s.Length() != 0 {
+		t.Error(len(seen), s.Length())
+	}
+
+	st := s.Stats()
+	if st.Pushed != 800 || st.Gotten != 800 || st.Evicted != 0 || st.Cap != 4*999 {
+		t.Error(st)
+	}
+}
+
+func TestShardedBufferBlockingGet(t *testing.T) {
+	s := NewShardedBuffer(3, 10, func(v interface{}) string {
+		return fmt.Sprint(v)
+	})
+
+	done := make(chan int)
+	for g := 0; g < 4; g++ {
+		go func() {
+			done <- s.Get().(int)
+		}()
+	}
+
+	sum := 0
+	for i := 1; i <= 4; i++ {
+		s.NBPush(i)
+	}
+	for g := 0; g < 4; g++ {
+		sum += <-done
+	}
+	if sum != 10 {
+		t.Error(sum)
+	}
+}
+
+func TestShardedBufferRoundRobin(t *testing.T) {
+	s := NewShardedBuffer(4, 10, nil)
+
+	for i := 0; i < 8; i++ {
+		s.NBPush(i)
+	}
+	for _, shard := range s.shards {
+		if shard.Length() != 2 {
+			t.Error(shard.Length())
+		}
+	}
+
+	seen := make(map[int]bool)
+	for i := 0; i < 8; i++ {
+		seen[s.Get().(int)] = true
+	}
+	if len(seen) != 8 || s.Length() != 0 {
+		t.Error(seen, s.Length())
+	}
+}
+
+func TestShardedBufferStats(t *testing.T) {
+	s := NewShardedBuffer(2, 3, nil)
+	s.shards[1].EvictedChan()
+	for i := 0; i < 10; i++ {
+		s.NBPush(i)
+	}
+	_, release := s.shards[0].ClaimBatch(context.Background(), 1)
+	release()
+
+	// A producer blocked on a full shard.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.shards[1].PushContext(ctx, 10) }()
+	for s.Stats().BlockedProducers != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	st := s.Stats()
+	if st.LastEviction.IsZero() {
+		t.Error(st)
+	}
+	st.LastEviction = time.Time{}
+	if st != (Stats{Pushed: 10, Evicted: 6, Gotten: 1, Processed: 1,
+		EvictedChanDropped: 1, Length: 3, Cap: 4, PeakLength: 2}) {
+		t.Error(st)
+	}
+}
@@ -0,0 +1,50 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Halve the buffer, down to the size passed to the constructor, when
+// gets and pops find it at most a quarter full for longer than after.
+// Together with WithGrowth returns the memory of a burst to the heap
+// once the buffer has been mostly idle for a while.
+func WithShrink(after time.Duration) Option {
+	return func(c *config) {
+		c.shrinkAfter = after
+	}
+}
+
+// Shrink the backing array to the smallest size, doubling from the
+// size passed to the constructor, that holds the items with room for
+// one more. Does nothing for a buffer that never grew, see WithGrowth
+// and Resize.
+func (b *Buffer[T]) Compact() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	size := b.initSize
+	for size-1 <= b.used() {
+		size *= 2
+	}
+	if size < b.size {
+		b.resizeLocked(size)
+		b.checkInvariants()
+	}
+}
+
+// Apply WithShrink. Must be called with the lock held, after removing
+// an item.
+func (b *Buffer[T]) shrinkLocked() {
+	if b.shrinkAfter == 0 || b.size <= b.initSize || b.used() > b.size/4 {
+		return
+	}
+	now := b.now()
+	if b.lowSince.IsZero() {
+		b.lowSince = now
+		return
+	}
+	if now.Sub(b.lowSince) >= b.shrinkAfter {
+		b.resizeLocked(max(b.size/2, b.initSize))
+		b.lowSince = time.Time{}
+	}
+}
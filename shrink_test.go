@@ -0,0 +1,83 @@
+package circularbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompact(t *testing.T) {
+	c := NewBuffer[int](4, WithGrowth(64))
+	for i := 0; i < 40; i++ {
+		c.NBPush(i)
+	}
+	if len(c.buffer) != 64 {
+		t.Error(len(c.buffer))
+	}
+	for i := 0; i < 35; i++ {
+		c.Get()
+	}
+	c.Compact()
+	// 5 items and room for one more.
+	if len(c.buffer) != 8 || c.Cap() != 7 {
+		t.Error(len(c.buffer), c.Cap())
+	}
+	if s := c.Snapshot(); len(s) != 5 || s[0] != 35 {
+		t.Error(s)
+	}
+
+	// Never below the initial size.
+	d := NewBuffer[int](16)
+	d.Compact()
+	if d.Cap() != 15 {
+		t.Error(d.Cap())
+	}
+
+	// A zero Buffer filled by UnmarshalJSON starts at the decoded size.
+	var e Buffer[int]
+	if err := e.UnmarshalJSON([]byte(`{"size":8,"items":[1,2]}`)); err != nil {
+		t.Error(err)
+	}
+	e.Compact()
+	if e.Cap() != 7 || e.Length() != 2 {
+		t.Error(e.Cap(), e.Length())
+	}
+}
+
+func TestWithShrink(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := NewBuffer[int](4, WithGrowth(32), WithShrink(time.Minute),
+		WithClock(func() time.Time { return now }))
+	for i := 0; i < 31; i++ {
+		c.NBPush(i)
+	}
+	// At a quarter full the clock starts.
+	for i := 0; i < 26; i++ {
+		c.Get()
+	}
+	if c.size != 32 {
+		t.Error(c.size)
+	}
+	now = now.Add(time.Minute)
+	c.Get()
+	if c.size != 16 {
+		t.Error(c.size)
+	}
+	// Low again, but a push above the threshold restarts the clock.
+	c.Get()
+	now = now.Add(30 * time.Second)
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	now = now.Add(30 * time.Second)
+	for i := 0; i < 5; i++ {
+		c.Get()
+	}
+	if c.size != 16 {
+		t.Error(c.size)
+	}
+	now = now.Add(time.Minute)
+	c.Get()
+	if c.size != 8 || c.Length() != 2 {
+		t.Error(c.size, c.Length())
+	}
+}
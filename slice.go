@@ -0,0 +1,45 @@
+package circularbuffer
+
+// Create Buffer of the given size holding the items, oldest first. If
+// there are more items than the buffer can hold, only the newest ones
+// are kept; the others are dropped without being evicted, as are items
+// that would be evicted by the options, e.g. WithLess or WithMaxCost.
+// Only the OnRecycle hook sees the dropped items. Counters start at
+// zero.
+func NewFromSlice[T any](items []T, size uint, opts ...Option) *Buffer[T] {
+	b := NewBuffer[T](size, opts...)
+	b.seedLocked(items)
+	b.checkInvariants()
+	return b
+}
+
+// Fill a new buffer with items, growing it if it's cost bounded or
+// WithGrowth allows, and dropping the oldest items that don't fit,
+// which are passed to OnRecycle. Must be called with the lock held,
+// before the buffer is shared.
+func (b *Buffer[T]) seedLocked(items []T) {
+	limit := uint(len(items))
+	if b.costOf == nil {
+		limit = min(limit, max(b.size, b.maxSize)-1)
+	}
+	b.hooks.recycle(items[:uint(len(items))-limit]...)
+	items = items[uint(len(items))-limit:]
+	if need := limit + 1; need > b.size {
+		b.resizeLocked(need)
+	}
+	for _, v := range items {
+		b.setCell(b.pos, v)
+		b.pos = (b.pos + 1) & b.mask
+	}
+	for b.costOf != nil && b.totalCost > b.maxCost && b.start != b.pos {
+		b.hooks.recycle(b.buffer[b.start])
+		b.clearCell(b.start)
+		b.start = (b.start + 1) & b.mask
+	}
+	b.lengthChangedLocked()
+}
+
+// Copy of the items, oldest first. Same as Snapshot.
+func (b *Buffer[T]) ToSlice() []T {
+	return b.Snapshot()
+}
@@ -0,0 +1,31 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestNewFromSlice(t *testing.T) {
+	c := NewFromSlice([]int{1, 2, 3, 4, 5}, 4)
+	if s := c.ToSlice(); len(s) != 3 || s[0] != 3 || s[2] != 5 {
+		t.Error(s)
+	}
+	if st := c.Stats(); st.Pushed != 0 || st.Evicted != 0 {
+		t.Error(st)
+	}
+	if v := c.NBPush(6); v != 3 {
+		t.Error(v)
+	}
+
+	var d *CircularBuffer = NewFromSlice([]interface{}{"a"}, 2)
+	if v := d.Get(); v != "a" {
+		t.Error(v)
+	}
+	if d.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+
+	e := NewFromSlice([]int{5, 5, 5}, 1, WithMaxCost(12, func(v int) int64 { return int64(v) }))
+	if s := e.ToSlice(); len(s) != 2 || e.Cost() != 10 {
+		t.Error(s)
+	}
+}
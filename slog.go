@@ -0,0 +1,80 @@
+package circularbuffer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// slog.Handler keeping the last records in a Buffer instead of
+// writing them out. When a record at or above the flush level comes
+// in, the kept records and then that one are replayed, oldest first,
+// to the next handler. The usual use is to log at debug level into the
+// ring and get the context of an error only when one happens.
+//
+// Handlers derived with WithAttrs and WithGroup share the ring, each
+// record is replayed through the handler it was logged with.
+type RingHandler struct {
+	ring       *Buffer[ringRecord]
+	next       slog.Handler
+	flushLevel slog.Level
+}
+
+type ringRecord struct {
+	next slog.Handler
+	r    slog.Record
+}
+
+var _ slog.Handler = (*RingHandler)(nil)
+
+// Create RingHandler keeping up to size records and replaying them to
+// next on records of flushLevel and above.
+func NewRingHandler(next slog.Handler, size uint, flushLevel slog.Level) *RingHandler {
+	return &RingHandler{
+		ring:       NewBuffer[ringRecord](size + 1),
+		next:       next,
+		flushLevel: flushLevel,
+	}
+}
+
+// All levels are kept.
+func (h *RingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+// Keep r, or replay the ring if r is at the flush level.
+func (h *RingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.ring.NBPush(ringRecord{next: h.next, r: r.Clone()})
+	if r.Level >= h.flushLevel {
+		return h.Replay(ctx)
+	}
+	return nil
+}
+
+// Replay the kept records to the next handler now, emptying the ring.
+// Records the next handler isn't enabled for are skipped. Returns the
+// errors of the next handler, joined.
+func (h *RingHandler) Replay(ctx context.Context) error {
+	var errs []error
+	for _, rec := range h.ring.DrainAll() {
+		if !rec.next.Enabled(ctx, rec.r.Level) {
+			continue
+		}
+		if err := rec.next.Handle(ctx, rec.r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
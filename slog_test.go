@@ -0,0 +1,50 @@
+package circularbuffer
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRingHandler(t *testing.T) {
+	var sb strings.Builder
+	next := slog.NewTextHandler(&sb, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	h := NewRingHandler(next, 3, slog.LevelError)
+	log := slog.New(h)
+
+	log.Debug("one")
+	log.Info("two", "n", 2)
+	log.With("req", 7).WithGroup("g").Debug("three", "n", 3)
+	if sb.Len() != 0 {
+		t.Error(sb.String())
+	}
+
+	// The oldest record fell out of the ring.
+	log.Error("boom")
+	want := `level=INFO msg=two n=2
+level=DEBUG msg=three req=7 g.n=3
+level=ERROR msg=boom
+`
+	if sb.String() != want {
+		t.Error(sb.String())
+	}
+
+	sb.Reset()
+	log.Warn("four")
+	h.Replay(context.Background())
+	if sb.String() != "level=WARN msg=four\n" {
+		t.Error(sb.String())
+	}
+	if h.ring.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,54 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Move the oldest n items, with their metadata, deadlines and keys, to
+// a new buffer with the same size and configuration (see Clone), in one
+// locked operation, e.g. to hand a chunk of backlog over to another
+// worker pool. The rest stays in b. Moved items are not counted as
+// taken or evicted and the hooks don't see them. n is clamped to the
+// number of items.
+func (b *Buffer[T]) Split(n int) *Buffer[T] {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c := b.emptyCloneLocked()
+	n = min(max(n, 0), int(b.used()))
+	for k := 0; k < n; k++ {
+		i := b.start
+		c.setCell(c.pos, b.buffer[i])
+		if b.meta != nil && b.meta[i] != nil {
+			if c.meta == nil {
+				c.meta = make([]interface{}, len(c.buffer))
+			}
+			c.meta[c.pos] = b.meta[i]
+		}
+		if b.expires != nil {
+			// Keep the deadline, setCell stamped a new one.
+			if c.expires == nil {
+				c.expires = make([]time.Time, len(c.buffer))
+			}
+			c.expires[c.pos] = b.expires[i]
+		}
+		if b.keys != nil && b.keys[i] != "" {
+			c.setKeyLocked(c.pos, b.keys[i])
+		}
+		c.pos = (c.pos + 1) & c.mask
+		b.clearCell(i)
+		b.start = (b.start + 1) & b.mask
+	}
+	c.lengthChangedLocked()
+	c.checkInvariants()
+
+	if n > 0 {
+		b.signalIdleLocked()
+		if b.space != nil {
+			b.space.Broadcast()
+		}
+		b.lengthChangedLocked()
+	}
+	b.checkInvariants()
+	return c
+}
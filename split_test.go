@@ -0,0 +1,45 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	c := NewBuffer[int](8)
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	c.PushKeyed("k", 5)
+	c.NBPushMeta(6, "m")
+
+	d := c.Split(3)
+	if s := d.Snapshot(); len(s) != 3 || s[0] != 0 || s[2] != 2 || d.Cap() != 7 {
+		t.Error(s)
+	}
+	if s := c.Snapshot(); len(s) != 4 || s[0] != 3 {
+		t.Error(s)
+	}
+
+	// Keys and metadata move along.
+	e := c.Split(100)
+	if c.Length() != 0 || e.Length() != 4 {
+		t.Error(c.Length(), e.Length())
+	}
+	if v := e.PushKeyed("k", 50); v != 5 {
+		t.Error(v)
+	}
+	e.Get()
+	e.Get()
+	if v, _, _ := e.GetMeta(); v != 50 {
+		t.Error(v)
+	}
+	if v, meta, _ := e.GetMeta(); v != 6 || meta != "m" {
+		t.Error(v, meta)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+	if s := c.Split(1); s.Length() != 0 {
+		t.Error(s.Length())
+	}
+}
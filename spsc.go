@@ -0,0 +1,102 @@
+package circularbuffer
+
+import (
+	"sync/atomic"
+)
+
+// Single-producer single-consumer ring of items, with no mutex and no
+// channel: each side owns one index and only reads the other one
+// atomically. Meant for real-time pipelines (audio, packet capture)
+// where a lock per item is too expensive.
+//
+// TryPush must only be called from one goroutine at a time, and
+// TryGet from one (possibly other) goroutine at a time. Nothing
+// blocks and nothing is evicted, as the producer can't touch the
+// consumer's index: a push to a full ring fails instead.
+type SPSCBuffer[T any] struct {
+	cells []T
+	mask  uint64
+
+	// Each side's fields on its own cache line. cachedTail and
+	// cachedHead are the last seen values of the other side's index,
+	// to avoid reading it on every call.
+	_          cacheLinePad
+	head       atomic.Uint64 // next slot to push to
+	cachedTail uint64
+	_          cacheLinePad
+	tail       atomic.Uint64 // next slot to get from
+	cachedHead uint64
+	_          cacheLinePad
+}
+
+// Create SPSCBuffer holding up to size items. The size is rounded up to
+// a power of two.
+func NewSPSCBuffer[T any](size uint) *SPSCBuffer[T] {
+	n := uint64(ringSize(size))
+	return &SPSCBuffer[T]{
+		cells: make([]T, n),
+		mask:  n - 1,
+	}
+}
+
+// Push v, or return ErrFull if the ring is full. Producer side.
+func (b *SPSCBuffer[T]) TryPush(v T) error {
+	head := b.head.Load()
+	if head-b.cachedTail == uint64(len(b.cells)) {
+		b.cachedTail = b.tail.Load()
+		if head-b.cachedTail == uint64(len(b.cells)) {
+			return ErrFull
+		}
+	}
+	b.cells[head&b.mask] = v
+	// Publish the item to the consumer.
+	b.head.Store(head + 1)
+	return nil
+}
+
+// Get the oldest item, ok is false if the ring is empty. Consumer
+// side.
+func (b *SPSCBuffer[T]) TryGet() (v T, ok bool) {
+	tail := b.tail.Load()
+	if tail == b.cachedHead {
+		b.cachedHead = b.head.Load()
+		if tail == b.cachedHead {
+			return v, false
+		}
+	}
+	i := tail & b.mask
+	v = b.cells[i]
+	var zero T
+	b.cells[i] = zero
+	// Hand the slot back to the producer.
+	b.tail.Store(tail + 1)
+	return v, true
+}
+
+// Number of items in the ring. Can be called from any goroutine, the
+// result is approximate under concurrent use.
+func (b *SPSCBuffer[T]) Length() int {
+	// Load tail first, so that the result is never negative.
+	tail := b.tail.Load()
+	head := b.head.Load()
+	return int(head - tail)
+}
+
+// Snapshot of the items, oldest first, without taking them. Consumer
+// side only, like TryGet: the consumer empties the slots it takes, so
+// reading them from another goroutine would race. Doesn't block the
+// producer, items it pushes meanwhile may be missed.
+func (b *SPSCBuffer[T]) PeekAll() []T {
+	tail := b.tail.Load()
+	head := b.head.Load()
+	items := make([]T, 0, head-tail)
+	for pos := tail; pos != head; pos++ {
+		items = append(items, b.cells[pos&b.mask])
+	}
+	return items
+}
+
+// Maximum number of items the ring can hold.
+func (b *SPSCBuffer[T]) Cap() int {
+	return len(b.cells)
+}
@@ -0,0 +1,144 @@
+package circularbuffer
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+func TestSPSCBuffer(t *testing.T) {
+	c := NewSPSCBuffer[int](3) // rounded up to 4
+	if c.Cap() != 4 {
+		t.Error(c.Cap())
+	}
+	if _, ok := c.TryGet(); ok {
+		t.Error("not empty")
+	}
+
+	for i := 1; i <= 4; i++ {
+		if err := c.TryPush(i); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := c.TryPush(5); err != ErrFull {
+		t.Error(err)
+	}
+	if c.Length() != 4 {
+		t.Error(c.Length())
+	}
+	for i := 1; i <= 4; i++ {
+		if v, ok := c.TryGet(); !ok || v != i {
+			t.Error(v, ok)
+		}
+	}
+	if c.Length() != 0 {
+		t.Error(c.Length())
+	}
+}
+
+func TestSPSCBufferConcurrent(t *testing.T) {
+	const n = 100000
+	c := NewSPSCBuffer[int](64)
+
+	go func() {
+		for i := 0; i < n; i++ {
+			for c.TryPush(i) != nil {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		v, ok := c.TryGet()
+		for !ok {
+			runtime.Gosched()
+			v, ok = c.TryGet()
+		}
+		if v != i {
+			t.Fatal(v, i)
+		}
+	}
+	if c.Length() != 0 {
+		t.Error(c.Length())
+	}
+}
+
+func TestSPSCBufferPeekAll(t *testing.T) {
+	const n = 10000
+	c := NewSPSCBuffer[int](64)
+	if items := c.PeekAll(); len(items) != 0 {
+		t.Error(items)
+	}
+
+	go func() {
+		for i := 1; i <= n; i++ {
+			for c.TryPush(i) != nil {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	// From the consumer side the snapshot starts at the next item and
+	// holds only pushed ones, in order.
+	for next := 1; next <= n; {
+		items := c.PeekAll()
+		for i, v := range items {
+			if v != next+i {
+				t.Fatal(items, next)
+			}
+		}
+		if v, ok := c.TryGet(); ok {
+			if v != next {
+				t.Fatal(v, next)
+			}
+			next++
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
+
+func BenchmarkSPSCPushGet(b *testing.B) {
+	c := NewSPSCBuffer[int](1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.TryPush(i)
+		c.TryGet()
+	}
+}
+
+func TestSPSCBufferLayout(t *testing.T) {
+	var b SPSCBuffer[int]
+	if unsafe.Offsetof(b.tail)-unsafe.Offsetof(b.cachedTail) < cacheLineSize {
+		t.Error(unsafe.Offsetof(b.tail))
+	}
+	if unsafe.Offsetof(b.head)-unsafe.Offsetof(b.mask) < cacheLineSize {
+		t.Error(unsafe.Offsetof(b.head))
+	}
+	if unsafe.Sizeof(b)-unsafe.Offsetof(b.cachedHead) < cacheLineSize {
+		t.Error(unsafe.Sizeof(b))
+	}
+}
+
+func BenchmarkSPSCProducerConsumer(b *testing.B) {
+	c := NewSPSCBuffer[int](1024)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < b.N; i++ {
+			for c.TryPush(i) != nil {
+				runtime.Gosched()
+			}
+		}
+		close(done)
+	}()
+	for i := 0; i < b.N; i++ {
+		for {
+			if _, ok := c.TryGet(); ok {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+	<-done
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+}
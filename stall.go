@@ -0,0 +1,70 @@
+package circularbuffer
+
+import (
+	"sync"
+	"time"
+)
+
+// Sustained overflow reported by WatchStall.
+type StallReport struct {
+	Since   time.Time     // when the buffer was first seen full and evicting
+	For     time.Duration // how long it has been so far
+	Dropped uint64        // items evicted since then
+}
+
+// Watch for a stalled consumer: call fn when the buffer has been full
+// and evicting for longer than after, and again every after while it
+// stays so, each time with the number of items evicted since it
+// started. The buffer is sampled from a goroutine about four times per
+// after, but at most once per millisecond; a consumer catching up for a
+// moment between samples may go unnoticed. fn runs on that goroutine,
+// without the lock. Call the returned function, any number of times, to
+// stop watching. Panics if after is not positive.
+func (b *Buffer[T]) WatchStall(after time.Duration, fn func(StallReport)) (stop func()) {
+	if after <= 0 {
+		panic("circularbuffer: WatchStall needs a positive duration")
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(max(after/4, time.Millisecond))
+		defer ticker.Stop()
+
+		var stalled bool
+		var since, reported, prevEviction time.Time
+		var evicted, dropped uint64
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			b.lock.Lock()
+			now := b.now()
+			full := b.fullLocked()
+			lastEviction, cur := b.lastEviction, b.evicted
+			b.lock.Unlock()
+
+			if cur < evicted {
+				// DrainStats reset the counter.
+				evicted = 0
+			}
+			if full && lastEviction.After(prevEviction) {
+				if !stalled {
+					stalled, since, reported, dropped = true, now, now, 0
+				}
+				dropped += cur - evicted
+			} else {
+				stalled = false
+			}
+			evicted, prevEviction = cur, lastEviction
+
+			if stalled && now.Sub(reported) >= after {
+				reported = now
+				fn(StallReport{Since: since, For: now.Sub(since), Dropped: dropped})
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,55 @@
+package circularbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchStall(t *testing.T) {
+	c := NewBuffer[int](4)
+	reports := make(chan StallReport, 10)
+	stop := c.WatchStall(20*time.Millisecond, func(r StallReport) { reports <- r })
+	defer stop()
+
+	// No consumer, keep pushing.
+	deadline := time.Now().Add(5 * time.Second)
+	var r StallReport
+loop:
+	for i := 0; time.Now().Before(deadline); i++ {
+		c.NBPush(i)
+		select {
+		case r = <-reports:
+			break loop
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if r.Dropped == 0 || r.For < 20*time.Millisecond {
+		t.Error(r)
+	}
+
+	// The consumer caught up, no more reports.
+	c.Reset(false)
+	time.Sleep(60 * time.Millisecond)
+	for len(reports) > 0 {
+		<-reports
+	}
+	time.Sleep(60 * time.Millisecond)
+	if len(reports) != 0 {
+		t.Error(<-reports)
+	}
+}
+
+func TestWatchStallArgs(t *testing.T) {
+	c := NewBuffer[int](4)
+	// Tiny durations are polled at most once per millisecond.
+	stop := c.WatchStall(time.Nanosecond, func(StallReport) {})
+	stop()
+	stop()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	c.WatchStall(0, func(StallReport) {})
+}
@@ -0,0 +1,143 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Counters at some point in time, for ThroughputPerSecond.
+type rateSample struct {
+	at       time.Time
+	pushed   uint64
+	consumed uint64
+}
+
+// Minimum age of the sample ThroughputPerSecond measures from.
+const rateInterval = time.Second
+
+// Number of pushes and consumed (got or popped) items per second.
+// Meant to be polled periodically for monitoring, by any number of
+// pollers: calls don't reset anything, the rate is measured from a
+// sample of the counters the buffer keeps and moves forward at most
+// once per second. So it covers the last one to two seconds when
+// polled at least once a second, otherwise the time since the previous
+// call, and the time since the buffer was created on the first call.
+func (b *Buffer[T]) ThroughputPerSecond() (pushRate, consumeRate float64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := b.now()
+	cur := rateSample{at: now, pushed: b.pushed, consumed: b.gotten + b.popped}
+	if now.Sub(b.rateNext.at) >= rateInterval {
+		b.rate, b.rateNext = b.rateNext, cur
+	}
+	elapsed := now.Sub(b.rate.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+
+	pushRate = float64(cur.pushed-b.rate.pushed) / elapsed
+	consumeRate = float64(cur.consumed-b.rate.consumed) / elapsed
+	return pushRate, consumeRate
+}
+
+// Start measuring throughput from now. Must be called with the lock
+// held.
+func (b *Buffer[T]) resetRateLocked() {
+	b.rate = rateSample{at: b.now()}
+	b.rateNext = b.rate
+}
+
+// Snapshot of the buffer counters.
+type Stats struct {
+	Pushed  uint64 // NBPush calls, including rejected items
+	Evicted uint64 // items evicted or rejected on overflow
+	Gotten  uint64 // items removed from the oldest end
+	Popped  uint64 // items removed from the newest end
+	Removed uint64 // items removed by RemoveIf
+
+	// Claimed items released after processing
+	Processed uint64
+
+	// Evicted items that didn't fit in EvictedChan
+	EvictedChanDropped uint64
+
+	Length int
+	Cap    int
+
+	// Highest Length seen
+	PeakLength int
+	// Time of the most recent eviction, zero if none. Not reset by
+	// DrainStats.
+	LastEviction time.Time
+
+	// Goroutines currently waiting in blocking gets and pushes
+	BlockedConsumers int
+	BlockedProducers int
+}
+
+// Cumulative operation counters since the buffer was created or
+// DrainStats was last called.
+func (b *Buffer[T]) Stats() Stats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.statsLocked()
+}
+
+// Like Stats, but atomically resets the operation counters, so that
+// periodic reporting doesn't count anything twice. PeakLength starts
+// over from the current length. Length and Cap are not affected, nor
+// are the blocked goroutine counts.
+func (b *Buffer[T]) DrainStats() Stats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s := b.statsLocked()
+	b.pushed, b.evicted, b.gotten, b.popped = 0, 0, 0, 0
+	b.removed, b.processed = 0, 0
+	b.peak = b.used()
+	if b.hooks.spill != nil {
+		s.EvictedChanDropped = b.hooks.spill.dropped.Swap(0)
+	}
+	// Rebase the throughput samples on the new counters. They may go
+	// below zero, the unsigned arithmetic still gives right deltas.
+	b.rate.pushed -= s.Pushed
+	b.rate.consumed -= s.Gotten + s.Popped
+	b.rateNext.pushed -= s.Pushed
+	b.rateNext.consumed -= s.Gotten + s.Popped
+	return s
+}
+
+// Reset the operation counters, as DrainStats does.
+func (b *Buffer[T]) ResetStats() {
+	b.DrainStats()
+}
+
+// Count an evicted item. Must be called with the lock held.
+func (b *Buffer[T]) noteEvictionLocked() {
+	b.evicted++
+	b.lastEviction = b.now()
+}
+
+func (b *Buffer[T]) statsLocked() Stats {
+	s := Stats{
+		Pushed:    b.pushed,
+		Evicted:   b.evicted,
+		Gotten:    b.gotten,
+		Popped:    b.popped,
+		Removed:   b.removed,
+		Processed: b.processed,
+		Length:    int(b.used()),
+		Cap:       b.capLocked(),
+
+		PeakLength:   int(b.peak),
+		LastEviction: b.lastEviction,
+
+		BlockedConsumers: b.blockedConsumers,
+		BlockedProducers: b.blockedProducers,
+	}
+	if b.hooks.spill != nil {
+		s.EvictedChanDropped = b.hooks.spill.dropped.Load()
+	}
+	return s
+}
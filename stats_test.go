@@ -0,0 +1,151 @@
+package circularbuffer
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestThroughputPerSecond(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	c := New(100, WithClock(clock.Now))
+
+	for i := 0; i < 50; i++ {
+		c.NBPush(i)
+	}
+	for i := 0; i < 20; i++ {
+		c.Get()
+	}
+	for i := 0; i < 10; i++ {
+		c.Pop()
+	}
+	clock.Advance(2 * time.Second)
+
+	push, consume := c.ThroughputPerSecond()
+	if math.Abs(push-25) > 0.001 || math.Abs(consume-15) > 0.001 {
+		t.Error(push, consume)
+	}
+
+	// Calls less than a second apart measure from the same sample,
+	// pollers don't disturb each other.
+	for i := 0; i < 10; i++ {
+		c.NBPush(i)
+	}
+	clock.Advance(500 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		push, consume = c.ThroughputPerSecond()
+		if math.Abs(push-24) > 0.001 || math.Abs(consume-12) > 0.001 {
+			t.Error(push, consume)
+		}
+	}
+
+	// Then the sample moves to the first call's.
+	clock.Advance(time.Second)
+	push, consume = c.ThroughputPerSecond()
+	if math.Abs(push-10/1.5) > 0.001 || consume != 0 {
+		t.Error(push, consume)
+	}
+
+	if c.Length() != 30 {
+		t.Error(c.Length())
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := NewCircularBuffer(4)
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	c.Get()
+	c.Pop()
+
+	s := c.Stats()
+	if s.LastEviction.IsZero() {
+		t.Error(s)
+	}
+	s.LastEviction = time.Time{}
+	if s != (Stats{Pushed: 5, Evicted: 2, Gotten: 1, Popped: 1, Length: 1, Cap: 3,
+		PeakLength: 3}) {
+		t.Error(s)
+	}
+}
+
+func TestDrainStats(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewCircularBuffer(4)
+	c.now = clock.Now
+	c.rate.at = clock.Now()
+
+	for i := 0; i < 5; i++ {
+		c.NBPush(i)
+	}
+	c.Get()
+
+	s := c.DrainStats()
+	if s != (Stats{Pushed: 5, Evicted: 2, Gotten: 1, Length: 2, Cap: 3,
+		PeakLength: 3, LastEviction: clock.Now()}) {
+		t.Error(s)
+	}
+
+	c.NBPush(5)
+	c.Pop()
+
+	s = c.DrainStats()
+	if s != (Stats{Pushed: 1, Popped: 1, Length: 2, Cap: 3,
+		PeakLength: 3, LastEviction: clock.Now()}) {
+		t.Error(s)
+	}
+	if s = c.Stats(); s != (Stats{Length: 2, Cap: 3, PeakLength: 2,
+		LastEviction: clock.Now()}) {
+		t.Error(s)
+	}
+
+	// Draining doesn't disturb the throughput calculation.
+	clock.Advance(time.Second)
+	push, consume := c.ThroughputPerSecond()
+	if push != 6 || consume != 2 {
+		t.Error(push, consume)
+	}
+}
+
+func TestStatsPeak(t *testing.T) {
+	now := time.Unix(100, 0)
+	c := NewBuffer[int](4, WithClock(func() time.Time { return now }))
+	c.NBPush(1)
+	c.NBPush(2)
+	c.Get()
+	if s := c.Stats(); s.PeakLength != 2 || !s.LastEviction.IsZero() {
+		t.Error(s)
+	}
+	c.NBPush(3)
+	c.NBPush(4)
+	now = now.Add(time.Second)
+	c.NBPush(5)
+	if s := c.Stats(); s.PeakLength != 3 || !s.LastEviction.Equal(now) {
+		t.Error(s)
+	}
+	c.Get()
+	c.ResetStats()
+	if s := c.Stats(); s.PeakLength != 2 || s.Pushed != 0 || !s.LastEviction.Equal(now) {
+		t.Error(s)
+	}
+	c.Get()
+	c.Get()
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,67 @@
+package circularbuffer
+
+// Replace the oldest item with v and return the old one, atomically.
+// If the buffer is empty or closed v is pushed as by NBPush instead
+// and ok is false. The swap counts as a push and an eviction in Stats
+// and hooks, but the Evict callback doesn't see the old item, it's
+// returned instead.
+func (b *Buffer[T]) SwapOldest(v T) (old T, ok bool) {
+	return b.swap(v, false)
+}
+
+// Replace the newest item with v and return the old one, atomically.
+// See SwapOldest.
+func (b *Buffer[T]) SwapNewest(v T) (old T, ok bool) {
+	return b.swap(v, true)
+}
+
+func (b *Buffer[T]) swap(v T, newest bool) (old T, ok bool) {
+	var evictbuf [1]T
+	evicted := evictbuf[:0]
+	stored := true
+	b.lock.Lock()
+	expired := b.expireLocked(nil)
+	if b.start == b.pos || b.closed {
+		var key string
+		if b.dedupKey != nil {
+			key = b.dedupKey(v)
+		}
+		var err error
+		evicted, stored, err = b.pushLocked(v, key, evicted)
+		if err == errPushPanic {
+			b.lock.Unlock()
+			panic("circularbuffer: push to closed buffer")
+		}
+	} else {
+		i := b.start
+		if newest {
+			i = (b.pos - 1) & b.mask
+		}
+		old, ok = b.buffer[i], true
+		b.clearCell(i)
+		b.setCell(i, v)
+		b.pushed++
+		b.noteEvictionLocked()
+		if b.recording {
+			kind := OpSwapOldest
+			if newest {
+				kind = OpSwapNewest
+			}
+			b.oplog = append(b.oplog, Op{Kind: kind, Value: v})
+		}
+		for b.costOf != nil && b.totalCost > b.maxCost && b.start != b.pos {
+			evicted = append(evicted, b.evictOldestLocked())
+		}
+	}
+	evict, hooks := b.Evict, b.hooks
+	b.checkInvariants()
+	b.lock.Unlock()
+
+	reportExpired(expired, evict, &hooks)
+	if ok {
+		hooks.evict(old)
+	}
+	hooks.pushed(v, stored, evicted)
+	hooks.handOff(evict, evicted...)
+	return old, ok
+}
@@ -0,0 +1,65 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	c := NewBuffer[string](4)
+
+	if old, ok := c.SwapNewest("a"); ok || old != "" {
+		t.Error(old, ok)
+	}
+	c.NBPush("b")
+	c.NBPushMeta("c", "meta")
+
+	if old, ok := c.SwapOldest("A"); !ok || old != "a" {
+		t.Error(old, ok)
+	}
+	if old, ok := c.SwapNewest("C"); !ok || old != "c" {
+		t.Error(old, ok)
+	}
+	if items := c.Snapshot(); len(items) != 3 || items[0] != "A" || items[1] != "b" || items[2] != "C" {
+		t.Error(items)
+	}
+	if s := c.Stats(); s.Pushed != 5 || s.Evicted != 2 || s.Length != 3 {
+		t.Error(s)
+	}
+
+	c.Get()
+	c.Get()
+	if v, meta, _ := c.GetMeta(); v != "C" || meta != nil {
+		t.Error(v, meta)
+	}
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
+
+func TestSwapCost(t *testing.T) {
+	c := NewCostBoundedBuffer(10, func(v interface{}) int64 {
+		return int64(v.(int))
+	})
+	c.NBPush(4)
+	c.NBPush(4)
+
+	// Growing the newest item evicts the oldest one.
+	if old, ok := c.SwapNewest(7); !ok || old != 4 {
+		t.Error(old, ok)
+	}
+	if c.Cost() != 7 || c.Length() != 1 {
+		t.Error(c.Cost(), c.Length())
+	}
+	if old, ok := c.SwapOldest(1); !ok || old != 7 {
+		t.Error(old, ok)
+	}
+	if c.Cost() != 1 {
+		t.Error(c.Cost())
+	}
+	c.Get()
+
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
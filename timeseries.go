@@ -0,0 +1,109 @@
+package circularbuffer
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample of a time series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// One level of a TimeSeriesRing: Size points, Step apart.
+type Resolution struct {
+	Step time.Duration
+	Size uint
+}
+
+// Fixed-memory history of a time series, RRDtool style. Points are
+// added to the first, finest level. Points falling out of a level are
+// not lost but averaged into the next, coarser one, one point per Step
+// of that level (aligned to the zero time), so old data survives at a
+// lower resolution, e.g. 1s for a minute, then 1m for an hour, then
+// 1h for a day. Only the last level drops points. Safe for concurrent
+// use.
+type TimeSeriesRing struct {
+	lock   sync.Mutex
+	levels []tsLevel
+}
+
+type tsLevel struct {
+	step   time.Duration
+	points *Buffer[Point]
+	// Average of the points evicted from the previous level into
+	// the current step of this one, not yet stored.
+	bucket time.Time
+	sum    float64
+	count  int
+}
+
+// Create TimeSeriesRing with the given levels, finest first. The Step
+// of the first level is the expected interval of Add, it's not
+// enforced.
+func NewTimeSeriesRing(levels ...Resolution) *TimeSeriesRing {
+	r := &TimeSeriesRing{levels: make([]tsLevel, len(levels))}
+	for i, res := range levels {
+		r.levels[i] = tsLevel{
+			step:   res.Step,
+			points: NewBuffer[Point](res.Size + 1),
+		}
+	}
+	return r
+}
+
+// Add a point, consolidating evicted ones into the coarser levels.
+// Points should come in time order.
+func (r *TimeSeriesRing) Add(t time.Time, v float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.store(0, Point{Time: t, Value: v})
+}
+
+// Store p in level i and push what falls out on to the next one.
+func (r *TimeSeriesRing) store(i int, p Point) {
+	res := r.levels[i].points.NBPushResult(p)
+	if !res.Evicted || i+1 == len(r.levels) {
+		return
+	}
+	next := &r.levels[i+1]
+	bucket := res.Value.Time.Truncate(next.step)
+	if next.count > 0 && !bucket.Equal(next.bucket) {
+		avg := Point{Time: next.bucket, Value: next.sum / float64(next.count)}
+		next.sum, next.count = 0, 0
+		r.store(i+1, avg)
+	}
+	next.bucket = bucket
+	next.sum += res.Value.Value
+	next.count++
+}
+
+// Points of level i, oldest first.
+func (r *TimeSeriesRing) Points(i int) []Point {
+	return r.levels[i].points.Snapshot()
+}
+
+// The whole history, oldest first, at the finest resolution available
+// for each period: points of a coarser level are included only if they
+// are older than everything in the finer ones.
+func (r *TimeSeriesRing) History() []Point {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var history []Point
+	for i := range r.levels {
+		points := r.levels[i].points.Snapshot()
+		if len(history) > 0 {
+			// Keep only what is older than the finer levels.
+			n := 0
+			for n < len(points) && points[n].Time.Before(history[0].Time) {
+				n++
+			}
+			points = points[:n]
+		}
+		history = append(points, history...)
+	}
+	return history
+}
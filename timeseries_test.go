@@ -0,0 +1,47 @@
+package circularbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeSeriesRing(t *testing.T) {
+	r := NewTimeSeriesRing(
+		Resolution{Step: time.Second, Size: 4},
+		Resolution{Step: 2 * time.Second, Size: 2},
+	)
+	t0 := time.Unix(1000, 0)
+	for i := 0; i < 10; i++ {
+		r.Add(t0.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	fine := r.Points(0)
+	if len(fine) != 4 || fine[0].Value != 6 || fine[3].Value != 9 {
+		t.Error(fine)
+	}
+	// 0..5 fell out, averaged in pairs. The (4, 5) pair is still
+	// pending.
+	coarse := r.Points(1)
+	if len(coarse) != 2 || coarse[0].Value != 0.5 || coarse[1].Value != 2.5 ||
+		!coarse[1].Time.Equal(t0.Add(2*time.Second)) {
+		t.Error(coarse)
+	}
+
+	h := r.History()
+	if len(h) != 6 || h[0].Value != 0.5 || h[1].Value != 2.5 || h[2].Value != 6 {
+		t.Error(h)
+	}
+	for i := 1; i < len(h); i++ {
+		if !h[i-1].Time.Before(h[i].Time) {
+			t.Error(h)
+		}
+	}
+
+	r.Add(t0.Add(10*time.Second), 10)
+	r.Add(t0.Add(11*time.Second), 11)
+	// 6, 7 fell out, flushing the (4, 5) pair and evicting (0, 1).
+	coarse = r.Points(1)
+	if len(coarse) != 2 || coarse[0].Value != 2.5 || coarse[1].Value != 4.5 {
+		t.Error(coarse)
+	}
+}
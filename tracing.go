@@ -0,0 +1,35 @@
+package circularbuffer
+
+import (
+	"context"
+)
+
+// Metadata carrying the producer's context, see NBPushWithContext.
+type itemContext struct {
+	ctx context.Context
+}
+
+// Nonblocking push of v carrying the values of the producer's ctx,
+// e.g. the OpenTelemetry span context, so that the consumer can get it
+// with GetWithContext and continue the trace. Only the values are
+// kept, not the deadline or cancellation: the producer's request is
+// likely over by the time the item is consumed. The context is stored
+// as the item's metadata, see NBPushMeta, so it replaces any other.
+//
+// There's no built-in OpenTelemetry instrumentation, to keep the
+// package free of dependencies; Hooks are the place to add span events
+// for pushes, gets and evictions.
+func (b *Buffer[T]) NBPushWithContext(ctx context.Context, v T) T {
+	return b.NBPushMeta(v, itemContext{context.WithoutCancel(ctx)})
+}
+
+// Get the oldest item without blocking, along with the context it was
+// pushed with by NBPushWithContext, or context.Background() if it was
+// pushed otherwise. ok is false if the buffer is empty.
+func (b *Buffer[T]) GetWithContext() (v T, ctx context.Context, ok bool) {
+	v, meta, ok := b.GetMeta()
+	if ic, isctx := meta.(itemContext); isctx {
+		return v, ic.ctx, ok
+	}
+	return v, context.Background(), ok
+}
@@ -0,0 +1,31 @@
+package circularbuffer
+
+import (
+	"context"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestNBPushWithContext(t *testing.T) {
+	c := NewBuffer[int](4)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "trace-1"))
+	c.NBPushWithContext(ctx, 1)
+	cancel()
+	c.NBPush(2)
+
+	v, ctx, ok := c.GetWithContext()
+	if !ok || v != 1 || ctx.Value(traceKey{}) != "trace-1" || ctx.Err() != nil {
+		t.Error(v, ctx, ok)
+	}
+	v, ctx, ok = c.GetWithContext()
+	if !ok || v != 2 || ctx != context.Background() {
+		t.Error(v, ctx, ok)
+	}
+	if _, _, ok = c.GetWithContext(); ok {
+		t.Error(ok)
+	}
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}
@@ -0,0 +1,264 @@
+package circularbuffer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// A write-ahead log file starts with the magic and the number of cells
+// of the ring as a uvarint, followed by records: a kind byte and the
+// cell index as a uvarint, then for walSet the item as a uvarint
+// length and the Codec encoding, for walMove the source cell.
+const walMagic = "CBWAL1"
+
+const (
+	walSet   byte = iota + 1 // store an item in an unused cell
+	walClear                 // drop the item in a cell
+	walMove                  // move an item to an unused cell
+)
+
+// Write-ahead log of a Buffer, see Recover.
+type wal[T any] struct {
+	path    string
+	f       *os.File
+	codec   *Codec[T]
+	used    []bool // cells holding an item, as of the logged records
+	rec     []byte // record being written, reused
+	item    []byte // encoded item, reused
+	records int    // since the last compaction
+	err     error  // first write error, logging stops after it
+}
+
+// Create Buffer whose contents are kept in an append-only log file at
+// path, rebuilding it from the file if it exists, e.g. after a crash.
+// Every change of the contents is appended to the log before the
+// operation returns, so a crash of the process loses nothing; use
+// SyncWAL to make it survive a crash of the machine. The log is
+// compacted to the current contents when it grows to a few times the
+// buffer size, and on Resize.
+//
+// Only the items are durable: metadata, deadlines, keys, counters and
+// the closed state are not logged. Items are encoded with the Codec
+// set by WithCodec. If the log holds more items than the buffer can,
+// the oldest are dropped. A crash in the middle of an operation
+// touching several items, like RemoveIf, may leave it half done.
+func Recover[T any](path string, size uint, opts ...Option) (*Buffer[T], error) {
+	b := NewBuffer[T](size, opts...)
+	codec, err := b.itemCodec()
+	if err != nil {
+		return nil, err
+	}
+	items, err := readWAL(path, codec)
+	if err != nil {
+		return nil, err
+	}
+	b.seedLocked(items)
+
+	b.wal = &wal[T]{path: path, codec: codec}
+	if err := b.wal.rebuild(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Rebuild the items, oldest first, from the log at path. A missing
+// file is an empty log, a torn record at the end is ignored.
+func readWAL[T any](path string, codec *Codec[T]) ([]T, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(walMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != walMagic {
+		return nil, errors.New("circularbuffer: not a write-ahead log")
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n == 0 || n&(n-1) != 0 {
+		return nil, errors.New("circularbuffer: bad write-ahead log header")
+	}
+	cells := make([]T, n)
+	used := make([]bool, n)
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		i, err := binary.ReadUvarint(r)
+		if err != nil || i >= n {
+			break
+		}
+		if kind == walClear {
+			var zero T
+			cells[i], used[i] = zero, false
+			continue
+		}
+		j, err := binary.ReadUvarint(r)
+		if err != nil {
+			break
+		}
+		if kind == walMove {
+			if j >= n {
+				break
+			}
+			var zero T
+			cells[i], cells[j] = cells[j], zero
+			used[i], used[j] = true, false
+			continue
+		}
+		data := make([]byte, j)
+		if _, err := io.ReadFull(r, data); err != nil {
+			break
+		}
+		v, err := codec.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		cells[i], used[i] = v, true
+	}
+
+	// The items are contiguous and there's always an unused cell, the
+	// oldest item is the one after it.
+	var start uint64
+	for i := uint64(0); i < n; i++ {
+		if used[i] && !used[(i-1)&(n-1)] {
+			start = i
+			break
+		}
+	}
+	var items []T
+	for k := uint64(0); k < n; k++ {
+		if i := (start + k) & (n - 1); used[i] {
+			items = append(items, cells[i])
+		}
+	}
+	return items, nil
+}
+
+// Append a record, compacting the log when it gets long. Must be
+// called with the lock held.
+func (w *wal[T]) log(b *Buffer[T], kind byte, i, j uint, v *T) {
+	if w.err != nil {
+		return
+	}
+	w.rec = append(w.rec[:0], kind)
+	w.rec = binary.AppendUvarint(w.rec, uint64(i))
+	switch kind {
+	case walClear:
+		w.used[i] = false
+	case walMove:
+		w.rec = binary.AppendUvarint(w.rec, uint64(j))
+		w.used[i], w.used[j] = true, false
+	case walSet:
+		w.used[i] = true
+		w.item = w.codec.Append(w.item[:0], *v)
+		w.rec = binary.AppendUvarint(w.rec, uint64(len(w.item)))
+		w.rec = append(w.rec, w.item...)
+	}
+	if _, w.err = w.f.Write(w.rec); w.err != nil {
+		return
+	}
+	w.records++
+	if w.records > 4*len(b.buffer)+64 {
+		w.err = w.compact(b)
+	}
+}
+
+// Start a new log from the items in the buffer, after the cells were
+// rearranged. Must be called with the lock held, between operations.
+func (w *wal[T]) rebuild(b *Buffer[T]) error {
+	w.used = make([]bool, len(b.buffer))
+	for i := b.start; i != b.pos; i = (i + 1) & b.mask {
+		w.used[i] = true
+	}
+	return w.compact(b)
+}
+
+// Replace the log with one holding only the items in the cells used as
+// of the logged records, which may be in the middle of an operation.
+// It's written aside and renamed over, so that a crash leaves either
+// log intact. Must be called with the lock held.
+func (w *wal[T]) compact(b *Buffer[T]) error {
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	bw.WriteString(walMagic)
+	w.rec = binary.AppendUvarint(w.rec[:0], uint64(len(b.buffer)))
+	bw.Write(w.rec)
+	for i, used := range w.used {
+		if !used {
+			continue
+		}
+		w.rec = append(w.rec[:0], walSet)
+		w.rec = binary.AppendUvarint(w.rec, uint64(i))
+		w.item = w.codec.Append(w.item[:0], b.buffer[i])
+		w.rec = binary.AppendUvarint(w.rec, uint64(len(w.item)))
+		w.rec = append(w.rec, w.item...)
+		bw.Write(w.rec)
+	}
+	err = bw.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, w.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if w.f != nil {
+		w.f.Close()
+	}
+	w.f, w.records = f, 0
+	return nil
+}
+
+// Flush the write-ahead log to disk, so that it survives a crash of
+// the machine. Returns the first error hit while writing the log, after
+// which it is not written anymore, or nil if the buffer has no log.
+func (b *Buffer[T]) SyncWAL() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.wal == nil {
+		return nil
+	}
+	if b.wal.err != nil {
+		return b.wal.err
+	}
+	return b.wal.f.Sync()
+}
+
+// Sync and close the write-ahead log, detaching it from the buffer.
+// Later changes are not logged.
+func (b *Buffer[T]) CloseWAL() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	w := b.wal
+	if w == nil {
+		return nil
+	}
+	b.wal = nil
+	err := w.err
+	if serr := w.f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package circularbuffer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal")
+	c, err := Recover[string](path, 4)
+	if err != nil || c.Length() != 0 {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		c.NBPush(s)
+	}
+	c.Get()
+	c.PushKeyed("k", "f")
+	c.Pop()
+	c.RemoveIf(func(v string) bool { return v == "d" })
+	c.NBPush("g")
+	if err := c.SyncWAL(); err != nil {
+		t.Error(err)
+	}
+	// Crash: the log is left as it is.
+	want := c.Snapshot()
+
+	d, err := Recover[string](path, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Snapshot(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Error(got, want)
+	}
+
+	// Enough pushes to compact the log a few times.
+	for i := 0; i < 1000; i++ {
+		d.NBPush(string(rune('a' + i%26)))
+	}
+	d.Resize(8)
+	d.NBPush("z")
+	want = d.Snapshot()
+	if err := d.CloseWAL(); err != nil {
+		t.Error(err)
+	}
+	d.NBPush("not logged")
+	if fi, err := os.Stat(path); err != nil || fi.Size() > 100 {
+		t.Error(fi.Size(), err)
+	}
+
+	// A torn record at the end is ignored. Fewer cells drop the
+	// oldest items.
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, data[:len(data)-1], 0o644)
+	e, err := Recover[string](path, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := e.Snapshot()
+	if len(got) != 3 || got[0] != want[len(want)-4] || got[2] != want[len(want)-2] {
+		t.Error(got, want)
+	}
+	e.CloseWAL()
+
+	if _, err := Recover[int](path, 4); err == nil {
+		t.Error("no codec")
+	}
+	os.WriteFile(path, []byte("junk"), 0o644)
+	if _, err := Recover[string](path, 4); err == nil {
+		t.Error("bad log")
+	}
+}
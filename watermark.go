@@ -0,0 +1,71 @@
+package circularbuffer
+
+import (
+	"time"
+)
+
+// Occupancy thresholds, see Watermarks.
+type watermarks struct {
+	low, high int
+	above     bool
+	ch        chan bool
+}
+
+// Get notified when the number of items reaches high, and again once
+// it's back down to low, so that producers can be throttled before the
+// buffer starts evicting and resumed when the consumer catches up.
+// The channel receives true on crossing high and false on crossing
+// low. It holds only the latest state: a notification the receiver
+// hasn't taken yet is replaced, so it's never stale and never blocks
+// the buffer. If the buffer is already at high, true is sent right
+// away. Replaces the watermarks set by a previous call, whose channel
+// receives nothing more. low must be below high.
+func (b *Buffer[T]) Watermarks(low, high int) <-chan bool {
+	if low >= high {
+		panic("circularbuffer: low watermark must be below high")
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.wm = &watermarks{low: low, high: high, ch: make(chan bool, 1)}
+	b.lengthChangedLocked()
+	return b.wm.ch
+}
+
+// Is the buffer above the high watermark, that is it reached high and
+// didn't go back down to low yet? False if no watermarks are set.
+func (b *Buffer[T]) AboveWatermark() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.wm != nil && b.wm.above
+}
+
+// Update the peak length and notify about a watermark crossing. Must
+// be called with the lock held, after changing the number of items.
+func (b *Buffer[T]) lengthChangedLocked() {
+	n := b.used()
+	b.peak = max(b.peak, n)
+	if n > b.size/4 {
+		b.lowSince = time.Time{}
+	}
+	w := b.wm
+	if w == nil {
+		return
+	}
+	switch {
+	case !w.above && int(n) >= w.high:
+		w.above = true
+	case w.above && int(n) <= w.low:
+		w.above = false
+	default:
+		return
+	}
+	// We're the only sender and hold the lock, once the stale
+	// notification is gone the send can't block.
+	select {
+	case <-w.ch:
+	default:
+	}
+	w.ch <- w.above
+}
@@ -0,0 +1,60 @@
+package circularbuffer
+
+import (
+	"testing"
+)
+
+func TestWatermarks(t *testing.T) {
+	c := NewBuffer[int](8)
+	c.NBPush(0)
+	c.NBPush(0)
+	c.NBPush(0)
+	ch := c.Watermarks(1, 3)
+	// Already at high.
+	if above := <-ch; !above || !c.AboveWatermark() {
+		t.Error(above)
+	}
+
+	// Between the watermarks nothing changes.
+	c.Get()
+	c.NBPush(0)
+	c.NBPush(0)
+	if len(ch) != 0 {
+		t.Error(len(ch))
+	}
+	c.Get()
+	c.Pop()
+	c.Get()
+	if above := <-ch; above || c.AboveWatermark() {
+		t.Error(above)
+	}
+
+	// Only the latest state is kept.
+	c.NBPush(0)
+	c.NBPush(0)
+	c.Get()
+	c.Get()
+	if len(ch) != 1 {
+		t.Error(len(ch))
+	}
+	if above := <-ch; above {
+		t.Error(above)
+	}
+
+	// Evictions and RemoveIf count too.
+	for i := 0; i < 10; i++ {
+		c.NBPush(i)
+	}
+	if above := <-ch; !above {
+		t.Error(above)
+	}
+	c.RemoveIf(func(v int) bool { return v > 3 })
+	if above := <-ch; above {
+		t.Error(above)
+	}
+
+	c.Reset(false)
+	if c.verifyIsEmpty() != true {
+		t.Error("not empty")
+	}
+}